@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/critiq17/critiqal-site/config"
 	_ "github.com/critiq17/critiqal-site/docs"
 	"github.com/critiq17/critiqal-site/internal/app"
 )
 
+// shutdownTimeout limits how long open requests and streams are waited for
+const shutdownTimeout = 10 * time.Second
+
 // @title Critiqal API
 // @version 1.0
 // @description This is  the API for Critiqal web-site
@@ -17,11 +25,31 @@ import (
 func main() {
 
 	cfg := config.LoadConfig()
-	app, err := app.SetupApp()
+
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// workers are stopped after the server, so views recorded by the last
+	// requests are still flushed. Streams end as soon as a signal arrives
+	workers, stopWorkers := context.WithCancel(context.Background())
+	app, waitWorkers, err := app.SetupApp(workers, signals)
 	if err != nil {
 		log.Fatal("Error setup app: ", err)
 	}
 
+	go func() {
+		<-signals.Done()
+		log.Println("Shutting down...")
+		if err := app.ShutdownWithTimeout(shutdownTimeout); err != nil {
+			log.Println("Error shutting down server: ", err)
+		}
+	}()
+
 	log.Println("Starting on port: " + cfg.Server.PORT)
-	app.Listen(":" + cfg.Server.PORT)
+	if err := app.Listen(":" + cfg.Server.PORT); err != nil {
+		log.Println("Server stopped: ", err)
+	}
+
+	stopWorkers()
+	waitWorkers()
 }
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +18,71 @@ type Server struct {
 type Config struct {
 	DatabaseConfig DatabaseConfig
 	Server         Server
+	Media          MediaConfig
+	Posts          PostsConfig
+	Previews       PreviewsConfig
+	Trending       TrendingConfig
+	Realtime       RealtimeConfig
+	Groups         GroupsConfig
+}
+
+type RealtimeConfig struct {
+	// PubSub is "memory" for a single replica or "postgres" to share
+	// events between replicas with LISTEN/NOTIFY on Channel
+	PubSub  string
+	Channel string
+	// Heartbeat is how often idle streams are pinged
+	Heartbeat time.Duration
+}
+
+type GroupsConfig struct {
+	// MaxMembers limits members of a group, including admins
+	MaxMembers int
+}
+
+type TrendingConfig struct {
+	// Interval is how often the ranking is computed
+	Interval time.Duration
+	// HalfLife is how long it takes an interaction to lose half its weight
+	HalfLife      time.Duration
+	Lookback      time.Duration
+	CommentWeight float64
+	RepostWeight  float64
+	MaxPosts      int
+	MaxTags       int
+	// TagWindows are sliding windows hashtags are ranked over
+	TagWindows []time.Duration
+}
+
+type PreviewsConfig struct {
+	// Timeout limits fetching of a linked page, including redirects
+	Timeout      time.Duration
+	MaxBodySize  int
+	MaxRedirects int
+	// TTL is how long a cached preview is served before it is refreshed
+	TTL time.Duration
+}
+
+type PostsConfig struct {
+	// SchedulerInterval is how often due scheduled posts are published
+	SchedulerInterval time.Duration
+	// EditWindow limits edits after publishing, zero means no limit
+	EditWindow time.Duration
+	// TrashRetention is how long deleted posts can be restored before
+	// they are purged every PurgeInterval
+	TrashRetention time.Duration
+	PurgeInterval  time.Duration
+	// ViewsFlushInterval is how often buffered views are written, a viewer
+	// is counted once per post per ViewsWindow
+	ViewsFlushInterval time.Duration
+	ViewsWindow        time.Duration
+}
+
+type MediaConfig struct {
+	// GCInterval is how often unreferenced media are collected
+	GCInterval time.Duration
+	// GCGracePeriod is how long unreferenced media are kept
+	GCGracePeriod time.Duration
 }
 
 type DatabaseConfig struct {
@@ -44,9 +111,53 @@ func LoadConfig() *Config {
 		Server: Server{
 			PORT: os.Getenv("PORT"),
 		},
+		Media: MediaConfig{
+			GCInterval:    getEnvDuration("MEDIA_GC_INTERVAL", time.Hour),
+			GCGracePeriod: getEnvDuration("MEDIA_GC_GRACE_PERIOD", 24*time.Hour),
+		},
+		Posts: PostsConfig{
+			SchedulerInterval: getEnvDuration("POST_SCHEDULER_INTERVAL", 30*time.Second),
+			EditWindow:        getEnvDuration("POST_EDIT_WINDOW", 0),
+			TrashRetention:    getEnvDuration("POST_TRASH_RETENTION", 30*24*time.Hour),
+			PurgeInterval:     getEnvDuration("POST_TRASH_PURGE_INTERVAL", time.Hour),
+
+			ViewsFlushInterval: getEnvDuration("POST_VIEWS_FLUSH_INTERVAL", 10*time.Second),
+			ViewsWindow:        getEnvDuration("POST_VIEWS_WINDOW", time.Hour),
+		},
+		Previews: PreviewsConfig{
+			Timeout:      getEnvDuration("LINK_PREVIEW_TIMEOUT", 5*time.Second),
+			MaxBodySize:  getEnvInt("LINK_PREVIEW_MAX_BODY_SIZE", 1<<20),
+			MaxRedirects: getEnvInt("LINK_PREVIEW_MAX_REDIRECTS", 3),
+			TTL:          getEnvDuration("LINK_PREVIEW_TTL", 24*time.Hour),
+		},
+		Trending: TrendingConfig{
+			Interval:      getEnvDuration("TRENDING_INTERVAL", 5*time.Minute),
+			HalfLife:      getEnvDuration("TRENDING_HALF_LIFE", 6*time.Hour),
+			Lookback:      getEnvDuration("TRENDING_LOOKBACK", 72*time.Hour),
+			CommentWeight: getEnvFloat("TRENDING_COMMENT_WEIGHT", 1),
+			RepostWeight:  getEnvFloat("TRENDING_REPOST_WEIGHT", 2),
+			MaxPosts:      getEnvInt("TRENDING_MAX_POSTS", 200),
+			MaxTags:       getEnvInt("TRENDING_MAX_TAGS", 20),
+			TagWindows:    getEnvDurations("TRENDING_TAG_WINDOWS", []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}),
+		},
+		Realtime: RealtimeConfig{
+			PubSub:    getEnv("PUBSUB_DRIVER", "memory"),
+			Channel:   getEnv("PUBSUB_CHANNEL", "critiqal_events"),
+			Heartbeat: getEnvDuration("STREAM_HEARTBEAT_INTERVAL", 25*time.Second),
+		},
+		Groups: GroupsConfig{
+			MaxMembers: getEnvInt("GROUP_MAX_MEMBERS", 50),
+		},
 	}
 }
 
+func getEnv(key, defaultValue string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	valStr := os.Getenv(key)
 	if valStr == "" {
@@ -61,6 +172,53 @@ func getEnvInt(key string, defaultValue int) int {
 	return val
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue
+	}
+
+	val, err := time.ParseDuration(valStr)
+	if err != nil || val <= 0 {
+		log.Printf("warning: cannot parse %s=%s as duration, using default %s", key, valStr, defaultValue)
+		return defaultValue
+	}
+	return val
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue
+	}
+
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil || val < 0 {
+		log.Printf("warning: cannot parse %s=%s as float, using default %g", key, valStr, defaultValue)
+		return defaultValue
+	}
+	return val
+}
+
+// getEnvDurations parses comma separated durations, e.g. "1h,24h"
+func getEnvDurations(key string, defaultValue []time.Duration) []time.Duration {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue
+	}
+
+	var vals []time.Duration
+	for _, part := range strings.Split(valStr, ",") {
+		val, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || val <= 0 {
+			log.Printf("warning: cannot parse %s=%s as durations, using default %v", key, valStr, defaultValue)
+			return defaultValue
+		}
+		vals = append(vals, val)
+	}
+	return vals
+}
+
 func (db *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		db.Host,
@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/bookmark"
+)
+
+type BookmarkCreateDTO struct {
+	// CollectionID puts bookmark to a collection, empty keeps it in the default list
+	CollectionID *string `json:"collection_id"`
+}
+
+type CollectionCreateDTO struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type CollectionResponseDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+func ToCollectionDTO(c *bookmark.Collection) *CollectionResponseDTO {
+	dto := &CollectionResponseDTO{
+		ID:   c.ID,
+		Name: c.Name,
+	}
+	if c.CreatedAt != nil {
+		dto.CreatedAt = c.CreatedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func ToCollectionsDTO(collections []*bookmark.Collection) []CollectionResponseDTO {
+	dtos := make([]CollectionResponseDTO, len(collections))
+	for i, c := range collections {
+		dtos[i] = *ToCollectionDTO(c)
+	}
+	return dtos
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/comment"
+)
+
+type CommentCreateDTO struct {
+	Body     string  `json:"body" binding:"required"`
+	ParentID *string `json:"parent_id"`
+}
+
+type CommentUpdateDTO struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type CommentResponseDTO struct {
+	ID        string               `json:"id"`
+	PostID    string               `json:"post_id"`
+	ParentID  *string              `json:"parent_id,omitempty"`
+	Author    *UserApi             `json:"author,omitempty"`
+	Body      string               `json:"body"`
+	Depth     int                  `json:"depth"`
+	Deleted   bool                 `json:"deleted"`
+	CreatedAt string               `json:"created_at"`
+	EditedAt  *string              `json:"edited_at,omitempty"`
+	Replies   []CommentResponseDTO `json:"replies"`
+	// RepliesCursor loads the rest of replies of a thread, it is set
+	// when the thread has more replies than are listed
+	RepliesCursor string `json:"replies_cursor,omitempty"`
+}
+
+type CommentsPageDTO struct {
+	Comments   []CommentResponseDTO `json:"comments"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+func ToCommentDomain(postID, authorID string, c *CommentCreateDTO) *comment.Comment {
+	if c == nil {
+		return nil
+	}
+	return &comment.Comment{
+		PostID:   postID,
+		AuthorID: authorID,
+		ParentID: c.ParentID,
+		Body:     c.Body,
+	}
+}
+
+// ToCommentDTO converts comment with its replies, body and author of
+// deleted comments are hidden
+func ToCommentDTO(c *comment.Comment) *CommentResponseDTO {
+	dto := &CommentResponseDTO{
+		ID:       c.ID,
+		PostID:   c.PostID,
+		ParentID: c.ParentID,
+		Depth:    c.Depth,
+		Deleted:  c.IsDeleted(),
+		Replies:  ToCommentsDTO(c.Replies),
+
+		RepliesCursor: c.RepliesCursor,
+	}
+
+	if !dto.Deleted {
+		dto.Author = ToUserApi(&c.Author)
+		dto.Body = c.Body
+		if c.EditedAt != nil {
+			editedAt := c.EditedAt.Format(time.RFC3339)
+			dto.EditedAt = &editedAt
+		}
+	}
+
+	if c.CreatedAt != nil {
+		dto.CreatedAt = c.CreatedAt.Format(time.RFC3339)
+	}
+
+	return dto
+}
+
+func ToCommentsDTO(comments []*comment.Comment) []CommentResponseDTO {
+	dtos := make([]CommentResponseDTO, len(comments))
+	for i, c := range comments {
+		dtos[i] = *ToCommentDTO(c)
+	}
+	return dtos
+}
@@ -0,0 +1,135 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/group"
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+)
+
+type GroupCreateDTO struct {
+	Title string `json:"title" binding:"required"`
+	// Usernames are members added besides the creator
+	Usernames []string `json:"usernames"`
+}
+
+type GroupUpdateDTO struct {
+	Title string `json:"title" binding:"required"`
+}
+
+type GroupMembersDTO struct {
+	Usernames []string `json:"usernames" binding:"required"`
+}
+
+type GroupRoleDTO struct {
+	// Role is "admin" or "member"
+	Role string `json:"role" binding:"required"`
+}
+
+type GroupMemberDTO struct {
+	User     *UserApi `json:"user,omitempty"`
+	Role     string   `json:"role"`
+	JoinedAt string   `json:"joined_at,omitempty"`
+}
+
+// GroupMessageDTO is a message of a group. Kind is "text" or a system
+// message: "created", "joined" or "left" with Subject who joined or left
+type GroupMessageDTO struct {
+	ID      string     `json:"id"`
+	GroupID string     `json:"group_id"`
+	Kind    string     `json:"kind"`
+	Sender  *UserApi   `json:"sender,omitempty"`
+	Subject *UserApi   `json:"subject,omitempty"`
+	Body    string     `json:"body"`
+	Media   []MediaDTO `json:"media"`
+	// ReadCount is the number of other members who have read the message
+	ReadCount int    `json:"read_count"`
+	CreatedAt string `json:"created_at"`
+}
+
+type GroupDTO struct {
+	ID          string           `json:"id"`
+	Title       string           `json:"title"`
+	Members     []GroupMemberDTO `json:"members"`
+	LastMessage *GroupMessageDTO `json:"last_message,omitempty"`
+	UnreadCount int              `json:"unread_count"`
+	CreatedAt   string           `json:"created_at"`
+}
+
+type GroupsPageDTO struct {
+	Groups     []GroupDTO `json:"groups"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type GroupMessagesPageDTO struct {
+	Messages   []GroupMessageDTO `json:"messages"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// ToGroupMessageDTO converts message of g, read receipts come from members of g
+func ToGroupMessageDTO(g *group.Group, m *message.Message) *GroupMessageDTO {
+	dto := &GroupMessageDTO{
+		ID:      m.ID,
+		GroupID: m.ConversationID,
+		Kind:    string(m.Kind),
+		Body:    m.Body,
+		Media:   ToMediaListDTO(m.Media, false),
+	}
+	if m.Sender.ID != "" {
+		dto.Sender = ToUserApi(&m.Sender)
+	}
+	if m.Subject.ID != "" {
+		dto.Subject = ToUserApi(&m.Subject)
+	}
+	if m.CreatedAt != nil {
+		dto.CreatedAt = m.CreatedAt.Format(time.RFC3339)
+		for _, member := range g.Members {
+			if member.UserID != m.SenderID && member.LastReadAt != nil && !member.LastReadAt.Before(*m.CreatedAt) {
+				dto.ReadCount++
+			}
+		}
+	}
+	return dto
+}
+
+func ToGroupMessagesDTO(g *group.Group, messages []*message.Message) []GroupMessageDTO {
+	dtos := make([]GroupMessageDTO, len(messages))
+	for i, m := range messages {
+		dtos[i] = *ToGroupMessageDTO(g, m)
+	}
+	return dtos
+}
+
+func ToGroupDTO(g *group.Group) *GroupDTO {
+	dto := &GroupDTO{
+		ID:          g.ID,
+		Title:       g.Title,
+		Members:     make([]GroupMemberDTO, 0, len(g.Members)),
+		UnreadCount: g.UnreadCount,
+	}
+	for _, member := range g.Members {
+		m := GroupMemberDTO{Role: string(member.Role)}
+		if member.User.ID != "" {
+			m.User = ToUserApi(&member.User)
+		}
+		if member.JoinedAt != nil {
+			m.JoinedAt = member.JoinedAt.Format(time.RFC3339)
+		}
+		dto.Members = append(dto.Members, m)
+	}
+	if g.LastMessage != nil {
+		dto.LastMessage = ToGroupMessageDTO(g, g.LastMessage)
+	}
+	if g.CreatedAt != nil {
+		dto.CreatedAt = g.CreatedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func ToGroupsDTO(list []*group.Group) []GroupDTO {
+	dtos := make([]GroupDTO, len(list))
+	for i, g := range list {
+		dtos[i] = *ToGroupDTO(g)
+	}
+	return dtos
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/critiq17/critiqal-site/internal/domain/media"
+
+type MediaDTO struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	AltText     string `json:"alt_text"`
+	Sensitive   bool   `json:"sensitive"`
+
+	Thumbnails map[int]string `json:"thumbnails"`
+}
+
+func ToMediaDTO(m *media.Media) *MediaDTO {
+	return &MediaDTO{
+		ID:          m.ID,
+		URL:         m.URL,
+		ContentType: m.ContentType,
+		Width:       m.Width,
+		Height:      m.Height,
+		AltText:     m.AltText,
+		Sensitive:   m.Sensitive,
+
+		Thumbnails: m.ThumbnailURLs(),
+	}
+}
+
+// ToMediaListDTO converts media of a post, all of them are sensitive
+// when the post is
+func ToMediaListDTO(list []media.Media, sensitive bool) []MediaDTO {
+	dtos := make([]MediaDTO, len(list))
+	for i := range list {
+		dtos[i] = *ToMediaDTO(&list[i])
+		dtos[i].Sensitive = dtos[i].Sensitive || sensitive
+	}
+	return dtos
+}
@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+)
+
+type ConversationOpenDTO struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type MessageCreateDTO struct {
+	Body string `json:"body"`
+	// MediaIDs are images uploaded with POST /api/conversations/media
+	MediaIDs []string `json:"media_ids"`
+}
+
+type MessageDTO struct {
+	ID             string     `json:"id"`
+	ConversationID string     `json:"conversation_id"`
+	Sender         *UserApi   `json:"sender,omitempty"`
+	Body           string     `json:"body"`
+	Media          []MediaDTO `json:"media"`
+	// Read reports whether the recipient has read the message
+	Read      bool   `json:"read"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ConversationDTO struct {
+	ID string `json:"id"`
+	// With is the other member of the conversation
+	With        *UserApi    `json:"with,omitempty"`
+	LastMessage *MessageDTO `json:"last_message,omitempty"`
+	UnreadCount int         `json:"unread_count"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+type ConversationsPageDTO struct {
+	Conversations []ConversationDTO `json:"conversations"`
+	NextCursor    string            `json:"next_cursor,omitempty"`
+}
+
+type MessagesPageDTO struct {
+	Messages   []MessageDTO `json:"messages"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func ToMessageDomain(m *MessageCreateDTO) *message.Message {
+	return &message.Message{
+		Body:     m.Body,
+		MediaIDs: m.MediaIDs,
+	}
+}
+
+// ToMessageDTO converts message of conv, read receipts come from members of conv
+func ToMessageDTO(conv *message.Conversation, m *message.Message) *MessageDTO {
+	dto := &MessageDTO{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		Body:           m.Body,
+		Media:          ToMediaListDTO(m.Media, false),
+	}
+	if m.Sender.ID != "" {
+		dto.Sender = ToUserApi(&m.Sender)
+	}
+	if recipient := conv.Other(m.SenderID); recipient != nil {
+		dto.Read = conv.IsReadBy(recipient.UserID, m)
+	}
+	if m.CreatedAt != nil {
+		dto.CreatedAt = m.CreatedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func ToMessagesDTO(conv *message.Conversation, messages []*message.Message) []MessageDTO {
+	dtos := make([]MessageDTO, len(messages))
+	for i, m := range messages {
+		dtos[i] = *ToMessageDTO(conv, m)
+	}
+	return dtos
+}
+
+// ToConversationDTO converts conversation as seen by userID
+func ToConversationDTO(userID string, c *message.Conversation) *ConversationDTO {
+	dto := &ConversationDTO{
+		ID:          c.ID,
+		UnreadCount: c.UnreadCount,
+	}
+	if other := c.Other(userID); other != nil && other.User.ID != "" {
+		dto.With = ToUserApi(&other.User)
+	}
+	if c.LastMessage != nil {
+		dto.LastMessage = ToMessageDTO(c, c.LastMessage)
+	}
+	if c.CreatedAt != nil {
+		dto.CreatedAt = c.CreatedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func ToConversationsDTO(userID string, list []*message.Conversation) []ConversationDTO {
+	dtos := make([]ConversationDTO, len(list))
+	for i, c := range list {
+		dtos[i] = *ToConversationDTO(userID, c)
+	}
+	return dtos
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
+)
+
+// NotificationDTO is a group of events, e.g. "3 people commented on your
+// post". Actors lists the latest actors, ActorsCount all of them
+type NotificationDTO struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	PostID      *string   `json:"post_id,omitempty"`
+	Actors      []UserApi `json:"actors"`
+	ActorsCount int       `json:"actors_count"`
+	Read        bool      `json:"read"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+}
+
+type NotificationsPageDTO struct {
+	Notifications []NotificationDTO `json:"notifications"`
+	UnreadCount   int64             `json:"unread_count"`
+	NextCursor    string            `json:"next_cursor,omitempty"`
+}
+
+func ToNotificationDTO(n *notification.Notification) *NotificationDTO {
+	dto := &NotificationDTO{
+		ID:          n.ID,
+		Type:        string(n.Type),
+		PostID:      n.PostID,
+		Actors:      make([]UserApi, len(n.Actors)),
+		ActorsCount: n.ActorsCount,
+		Read:        n.IsRead(),
+		UpdatedAt:   n.UpdatedAt.Format(time.RFC3339),
+	}
+	for i := range n.Actors {
+		dto.Actors[i] = *ToUserApi(&n.Actors[i])
+	}
+	if n.CreatedAt != nil {
+		dto.CreatedAt = n.CreatedAt.Format(time.RFC3339)
+	}
+	return dto
+}
+
+func ToNotificationsDTO(list []*notification.Notification) []NotificationDTO {
+	dtos := make([]NotificationDTO, len(list))
+	for i, n := range list {
+		dtos[i] = *ToNotificationDTO(n)
+	}
+	return dtos
+}
@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+)
+
+type PollCreateDTO struct {
+	// Options are 2 to 4 answers, in display order
+	Options  []string  `json:"options" binding:"required"`
+	Multiple bool      `json:"multiple"`
+	ClosesAt time.Time `json:"closes_at" binding:"required"`
+}
+
+type PollVoteDTO struct {
+	// Choices are positions of picked options, exactly one unless poll is multiple choice
+	Choices []int `json:"choices" binding:"required"`
+}
+
+// PollDTO holds tallies only when results_visible is set, that is after
+// the caller voted or the poll closed
+type PollDTO struct {
+	Multiple       bool            `json:"multiple"`
+	ClosesAt       time.Time       `json:"closes_at"`
+	Closed         bool            `json:"closed"`
+	Options        []PollOptionDTO `json:"options"`
+	VotersCount    *int            `json:"voters_count,omitempty"`
+	Voted          bool            `json:"voted"`
+	OwnChoices     []int           `json:"own_choices"`
+	ResultsVisible bool            `json:"results_visible"`
+}
+
+type PollOptionDTO struct {
+	Position   int    `json:"position"`
+	Text       string `json:"text"`
+	VotesCount *int   `json:"votes_count,omitempty"`
+}
+
+func ToPollDomain(p *PollCreateDTO) *post.Poll {
+	if p == nil {
+		return nil
+	}
+
+	poll := &post.Poll{
+		Multiple: p.Multiple,
+		ClosesAt: p.ClosesAt,
+		Options:  make([]post.PollOption, len(p.Options)),
+	}
+	for i, text := range p.Options {
+		poll.Options[i] = post.PollOption{Position: i, Text: text}
+	}
+	return poll
+}
+
+func ToPollDTO(p *post.Poll) *PollDTO {
+	if p == nil {
+		return nil
+	}
+
+	dto := &PollDTO{
+		Multiple:       p.Multiple,
+		ClosesAt:       p.ClosesAt,
+		Closed:         p.IsClosed(time.Now()),
+		Options:        make([]PollOptionDTO, len(p.Options)),
+		Voted:          p.Voted(),
+		OwnChoices:     p.OwnChoices,
+		ResultsVisible: p.ResultsVisible,
+	}
+	if dto.OwnChoices == nil {
+		dto.OwnChoices = []int{}
+	}
+	if p.ResultsVisible {
+		dto.VotersCount = &p.VotersCount
+	}
+
+	for i := range p.Options {
+		o := &p.Options[i]
+		dto.Options[i] = PollOptionDTO{
+			Position: o.Position,
+			Text:     o.Text,
+		}
+		if p.ResultsVisible {
+			dto.Options[i].VotesCount = &o.VotesCount
+		}
+	}
+
+	return dto
+}
@@ -7,10 +7,29 @@ import (
 )
 
 type PostCreateDTO struct {
-	OwnerID     string  `json:"owner_id" binding:"required"`
-	PhotoURL    *string `json:"photo_url"`
-	Description string  `json:"description" binding:"required"`
-	Title       *string `json:"title"`
+	OwnerID     string   `json:"owner_id" binding:"required"`
+	PhotoURL    *string  `json:"photo_url"`
+	Description string   `json:"description" binding:"required"`
+	Title       *string  `json:"title"`
+	QuoteOfID   *string  `json:"quote_of_id"`
+	MediaIDs    []string `json:"media_ids"`
+
+	// Visibility is "public" (default), "followers", "mentioned" or "unlisted"
+	Visibility string `json:"visibility"`
+
+	// Status is "draft", "scheduled" or "published" (default), scheduled
+	// posts require PublishAt
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+
+	Poll *PollCreateDTO `json:"poll"`
+
+	// ContentWarning hides the post behind a warning, e.g. a spoiler
+	ContentWarning *string `json:"content_warning"`
+	// Sensitive marks all media as sensitive, SensitiveMediaIDs only
+	// some of media_ids
+	Sensitive         bool     `json:"sensitive"`
+	SensitiveMediaIDs []string `json:"sensitive_media_ids"`
 }
 
 type PostResponseDTO struct {
@@ -18,14 +37,93 @@ type PostResponseDTO struct {
 	Author    UserApi `json:"author"`
 	Title     *string `json:"title,omitempty"`
 	Body      string  `json:"body"`
+	BodyHTML  string  `json:"body_html"`
 	CreatedAt string  `json:"created_at"`
 	ImageURL  *string `json:"image_url,omitempty"`
+
+	Kind                string           `json:"kind"`
+	Original            *PostResponseDTO `json:"original,omitempty"`
+	OriginalUnavailable bool             `json:"original_unavailable,omitempty"`
+
+	Tags     []string     `json:"tags"`
+	Mentions []MentionDTO `json:"mentions"`
+	Media    []MediaDTO   `json:"media"`
+
+	CommentsCount int `json:"comments_count"`
+	RepostsCount  int `json:"reposts_count"`
+
+	Visibility string     `json:"visibility"`
+	Status     string     `json:"status"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+	RevisionsCount int        `json:"revisions_count"`
+
+	// DeletedAt is set for posts in trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// ViewsCount is returned to the post owner only
+	ViewsCount *int64 `json:"views_count,omitempty"`
+
+	Pinned     bool `json:"pinned"`
+	Bookmarked bool `json:"bookmarked"`
+
+	Poll        *PollDTO        `json:"poll,omitempty"`
+	LinkPreview *LinkPreviewDTO `json:"link_preview,omitempty"`
+
+	ContentWarning *string `json:"content_warning,omitempty"`
+	Sensitive      bool    `json:"sensitive"`
+	// MediaCollapsed tells feeds to hide sensitive media behind a click,
+	// it follows preferences of the caller
+	MediaCollapsed bool `json:"media_collapsed"`
+}
+
+// LinkPreviewDTO is OpenGraph metadata of the first link in body
+type LinkPreviewDTO struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// PostRevisionDTO is a previous version of an edited post
+type PostRevisionDTO struct {
+	ID         string  `json:"id"`
+	Title      *string `json:"title,omitempty"`
+	Body       string  `json:"body"`
+	BodyHTML   string  `json:"body_html"`
+	ImageURL   *string `json:"image_url,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	ReplacedAt string  `json:"replaced_at"`
+}
+
+// MentionDTO points to "@username" in body, offsets are in UTF-16 code units
+type MentionDTO struct {
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+type PostsPageDTO struct {
+	Posts      []PostResponseDTO `json:"posts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
 
 type PostUpdateDTO struct {
 	PhotoURL    *string `json:"photo_url"`
 	Description string  `json:"description" binding:"required"`
 	Title       *string `json:"title"`
+
+	// Status and PublishAt change status of drafts, empty keeps it
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+
+	// ContentWarning, Sensitive and SensitiveMediaIDs are kept when
+	// omitted, empty content_warning removes the warning
+	ContentWarning    *string  `json:"content_warning"`
+	Sensitive         *bool    `json:"sensitive"`
+	SensitiveMediaIDs []string `json:"sensitive_media_ids"`
 }
 
 func ToPostDomain(p *PostCreateDTO) *post.Post {
@@ -37,6 +135,16 @@ func ToPostDomain(p *PostCreateDTO) *post.Post {
 		PhotoURL:    p.PhotoURL,
 		Description: p.Description,
 		Title:       p.Title,
+		OriginalID:  p.QuoteOfID,
+		MediaIDs:    p.MediaIDs,
+		Visibility:  post.Visibility(p.Visibility),
+		Status:      post.Status(p.Status),
+		PublishAt:   p.PublishAt,
+		Poll:        ToPollDomain(p.Poll),
+
+		ContentWarning:    p.ContentWarning,
+		Sensitive:         &p.Sensitive,
+		SensitiveMediaIDs: p.SensitiveMediaIDs,
 	}
 }
 
@@ -49,10 +157,57 @@ func ToPostDTO(p *post.Post) *PostResponseDTO {
 			FirstName: p.Owner.FirstName,
 			LastName:  p.Owner.LastName,
 			PhotoURL:  p.Owner.PhotoURL,
+
+			PhotoThumbnails: p.Owner.PhotoThumbnails,
 		},
 		Title:    p.Title,
 		Body:     p.Description,
+		BodyHTML: p.DescriptionHTML,
 		ImageURL: p.PhotoURL,
+
+		Kind:                string(p.Kind),
+		OriginalUnavailable: p.OriginalUnavailable(),
+
+		Tags:     p.Tags,
+		Mentions: ToMentionsDTO(p.Mentions),
+		Media:    ToMediaListDTO(p.Media, p.IsSensitive()),
+
+		CommentsCount: p.CommentsCount,
+		RepostsCount:  p.RepostsCount,
+
+		Visibility: string(p.Visibility),
+		Status:     string(p.Status),
+		PublishAt:  p.PublishAt,
+
+		EditedAt:       p.EditedAt,
+		RevisionsCount: p.RevisionsCount,
+
+		DeletedAt: p.DeletedAt,
+
+		ViewsCount: p.ViewsCount,
+
+		Pinned:     p.PinnedAt != nil,
+		Bookmarked: p.Bookmarked,
+
+		Poll: ToPollDTO(p.Poll),
+
+		ContentWarning: p.ContentWarning,
+		Sensitive:      p.IsSensitive(),
+		MediaCollapsed: p.MediaCollapsed,
+	}
+
+	if p.LinkPreview != nil {
+		dto.LinkPreview = &LinkPreviewDTO{
+			URL:         p.LinkPreview.URL,
+			Title:       p.LinkPreview.Title,
+			Description: p.LinkPreview.Description,
+			ImageURL:    p.LinkPreview.ImageURL,
+			SiteName:    p.LinkPreview.SiteName,
+		}
+	}
+
+	if p.Original != nil {
+		dto.Original = ToPostDTO(p.Original)
 	}
 
 	if p.CreatedAt != nil {
@@ -80,5 +235,43 @@ func ToPostDomainFromUpdateDTO(p *PostUpdateDTO) *post.Post {
 		PhotoURL:    p.PhotoURL,
 		Description: p.Description,
 		Title:       p.Title,
+		Status:      post.Status(p.Status),
+		PublishAt:   p.PublishAt,
+
+		ContentWarning:    p.ContentWarning,
+		Sensitive:         p.Sensitive,
+		SensitiveMediaIDs: p.SensitiveMediaIDs,
 	}
 }
+
+func ToMentionsDTO(mentions []post.Mention) []MentionDTO {
+	dtos := make([]MentionDTO, len(mentions))
+	for i, m := range mentions {
+		dtos[i] = MentionDTO{
+			Username: m.Username,
+			Start:    m.Start,
+			End:      m.End,
+		}
+	}
+	return dtos
+}
+
+func ToPostRevisionsDTO(revisions []*post.Revision) []PostRevisionDTO {
+	dtos := make([]PostRevisionDTO, len(revisions))
+	for i, r := range revisions {
+		dtos[i] = PostRevisionDTO{
+			ID:       r.ID,
+			Title:    r.Title,
+			Body:     r.Description,
+			BodyHTML: r.DescriptionHTML,
+			ImageURL: r.PhotoURL,
+		}
+		if r.CreatedAt != nil {
+			dtos[i].CreatedAt = r.CreatedAt.Format(time.RFC3339)
+		}
+		if r.ReplacedAt != nil {
+			dtos[i].ReplacedAt = r.ReplacedAt.Format(time.RFC3339)
+		}
+	}
+	return dtos
+}
@@ -0,0 +1,22 @@
+package dto
+
+// PostCountsDTO is sent on stream when comments or reposts of a post change
+type PostCountsDTO struct {
+	PostID        string `json:"post_id"`
+	CommentsCount int    `json:"comments_count"`
+	RepostsCount  int    `json:"reposts_count"`
+}
+
+// NotificationEventDTO is sent on stream when a notification is added,
+// clients reload GET /api/notifications
+type NotificationEventDTO struct {
+	Type   string `json:"type"`
+	PostID string `json:"post_id,omitempty"`
+}
+
+// MessageEventDTO is sent on stream when a message arrives in a
+// conversation or the other member reads it
+type MessageEventDTO struct {
+	ConversationID string `json:"conversation_id"`
+	MessageID      string `json:"message_id,omitempty"`
+}
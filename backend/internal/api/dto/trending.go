@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/critiq17/critiqal-site/internal/domain/trending"
+
+type TrendingTagDTO struct {
+	Tag          string `json:"tag"`
+	PostsCount   int    `json:"posts_count"`
+	AuthorsCount int    `json:"authors_count"`
+}
+
+func ToTrendingTagsDTO(tags []trending.TagScore) []TrendingTagDTO {
+	dtos := make([]TrendingTagDTO, len(tags))
+	for i, t := range tags {
+		dtos[i] = TrendingTagDTO{
+			Tag:          t.Tag,
+			PostsCount:   t.Posts,
+			AuthorsCount: t.Authors,
+		}
+	}
+	return dtos
+}
@@ -10,6 +10,8 @@ type UserApi struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	PhotoURL  string `json:"photo_url"`
+
+	PhotoThumbnails map[int]string `json:"photo_thumbnails,omitempty"`
 }
 
 type CreateRequest struct {
@@ -20,6 +22,21 @@ type CreateRequest struct {
 	LastName  string `json:"last_name"`
 }
 
+// PreferencesDTO are settings of the current user, omitted fields are kept
+type PreferencesDTO struct {
+	ExpandSensitiveMedia *bool `json:"expand_sensitive_media"`
+}
+
+type PreferencesResponseDTO struct {
+	ExpandSensitiveMedia bool `json:"expand_sensitive_media"`
+}
+
+func ToPreferencesDTO(p user.Preferences) *PreferencesResponseDTO {
+	return &PreferencesResponseDTO{
+		ExpandSensitiveMedia: p.ExpandSensitiveMedia,
+	}
+}
+
 func ToUserApi(u *user.User) *UserApi {
 	if u == nil {
 		return nil
@@ -32,6 +49,8 @@ func ToUserApi(u *user.User) *UserApi {
 		FirstName: u.FirstName,
 		LastName:  u.LastName,
 		PhotoURL:  u.PhotoURL,
+
+		PhotoThumbnails: u.PhotoThumbnails,
 	}
 }
 
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/bookmark"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/gofiber/fiber/v2"
+)
+
+// BookmarkPost saves post to bookmarks of the current user
+// @Summary Bookmark post
+// @Description Bookmark post, optionally into a collection. Bookmarking post again moves it to the given collection
+// @Tags bookmarks
+// @Accept json
+// @Param id path string true "Post ID"
+// @Param bookmark body dto.BookmarkCreateDTO false "Collection"
+// @Success 200 {object} map[string]string "successfully bookmarked"
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 404 {object} map[string]string "post or collection not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/bookmark [post]
+func (h *Handlers) BookmarkPost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	var req dto.BookmarkCreateDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid input",
+			})
+		}
+	}
+	if req.CollectionID != nil && *req.CollectionID == "" {
+		req.CollectionID = nil
+	}
+
+	err := h.bookmarkService.Save(context.Background(), userID, postID, req.CollectionID)
+	switch {
+	case errors.Is(err, bookmark.ErrPostNotFound), errors.Is(err, bookmark.ErrCollectionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to bookmark post",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully bookmarked post",
+	})
+}
+
+// UnbookmarkPost removes post from bookmarks of the current user
+// @Summary Remove bookmark
+// @Description Remove post from bookmarks
+// @Tags bookmarks
+// @Param id path string true "Post ID"
+// @Success 200 {object} map[string]string "successfully removed"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/bookmark [delete]
+func (h *Handlers) UnbookmarkPost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	if err := h.bookmarkService.Remove(context.Background(), userID, postID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to remove bookmark",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully removed bookmark",
+	})
+}
+
+// GetMyBookmarks retrieves posts bookmarked by the current user
+// @Summary Get my bookmarks
+// @Description Get bookmarked posts, newest bookmark first, with cursor pagination
+// @Tags bookmarks
+// @Produce json
+// @Param collection_id query string false "Only bookmarks of this collection"
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.PostsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 404 {object} map[string]string "collection not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/bookmarks [get]
+func (h *Handlers) GetMyBookmarks(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var collectionID *string
+	if id := c.Query("collection_id"); id != "" {
+		if err := h.bookmarkService.CheckCollection(context.Background(), userID, id); err != nil {
+			if errors.Is(err, bookmark.ErrCollectionNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to get bookmarks",
+			})
+		}
+		collectionID = &id
+	}
+
+	posts, next, err := h.postService.GetBookmarks(context.Background(), userID, collectionID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if errors.Is(err, cursor.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get bookmarks",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.PostsPageDTO{
+		Posts:      dto.ToPostsDTO(posts),
+		NextCursor: next,
+	})
+}
+
+// GetBookmarkCollections retrieves bookmark collections of the current user
+// @Summary Get bookmark collections
+// @Tags bookmarks
+// @Produce json
+// @Success 200 {array} dto.CollectionResponseDTO
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/bookmarks/collections [get]
+func (h *Handlers) GetBookmarkCollections(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	collections, err := h.bookmarkService.ListCollections(context.Background(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get collections",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToCollectionsDTO(collections))
+}
+
+// CreateBookmarkCollection creates a bookmark collection
+// @Summary Create bookmark collection
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param collection body dto.CollectionCreateDTO true "Collection"
+// @Success 201 {object} dto.CollectionResponseDTO
+// @Failure 400 {object} map[string]string "invalid name"
+// @Failure 409 {object} map[string]string "collection already exists"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/bookmarks/collections [post]
+func (h *Handlers) CreateBookmarkCollection(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.CollectionCreateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	collection, err := h.bookmarkService.CreateCollection(context.Background(), userID, req.Name)
+	if err != nil {
+		return collectionError(c, err, "failed to create collection")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToCollectionDTO(collection))
+}
+
+// RenameBookmarkCollection renames a bookmark collection
+// @Summary Rename bookmark collection
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Param collection_id path string true "Collection ID"
+// @Param collection body dto.CollectionCreateDTO true "Collection"
+// @Success 200 {object} dto.CollectionResponseDTO
+// @Failure 400 {object} map[string]string "invalid name"
+// @Failure 404 {object} map[string]string "collection not found"
+// @Failure 409 {object} map[string]string "collection already exists"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/bookmarks/collections/{collection_id} [put]
+func (h *Handlers) RenameBookmarkCollection(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.CollectionCreateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	collection, err := h.bookmarkService.RenameCollection(context.Background(), userID, c.Params("collection_id"), req.Name)
+	if err != nil {
+		return collectionError(c, err, "failed to rename collection")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToCollectionDTO(collection))
+}
+
+// DeleteBookmarkCollection deletes a bookmark collection, its bookmarks
+// move to the default list
+// @Summary Delete bookmark collection
+// @Tags bookmarks
+// @Param collection_id path string true "Collection ID"
+// @Success 200 {object} map[string]string "successfully deleted"
+// @Failure 404 {object} map[string]string "collection not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/bookmarks/collections/{collection_id} [delete]
+func (h *Handlers) DeleteBookmarkCollection(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.bookmarkService.DeleteCollection(context.Background(), userID, c.Params("collection_id")); err != nil {
+		return collectionError(c, err, "failed to delete collection")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully deleted collection",
+	})
+}
+
+func collectionError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, bookmark.ErrInvalidName):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, bookmark.ErrCollectionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, bookmark.ErrCollectionExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": msg,
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/comment"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/gofiber/fiber/v2"
+)
+
+// commentErrorStatus maps comment service errors to http status codes
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, comment.ErrNotFound), errors.Is(err, comment.ErrPostNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, comment.ErrForbidden):
+		return fiber.StatusForbidden
+	case errors.Is(err, comment.ErrEmptyBody),
+		errors.Is(err, comment.ErrBodyTooLong),
+		errors.Is(err, comment.ErrMaxDepth),
+		errors.Is(err, comment.ErrDeleted),
+		errors.Is(err, comment.ErrWrongPost),
+		errors.Is(err, cursor.ErrInvalid):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// CreateComment adds a comment or a reply to a post
+// @Summary Create comment
+// @Description Create a comment on a post, set parent_id to reply to another comment
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param comment body dto.CommentCreateDTO true "Comment data"
+// @Success 201 {object} dto.CommentResponseDTO
+// @Failure 400 {object} map[string]string "invalid body"
+// @Failure 404 {object} map[string]string "post or parent not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/comments [post]
+func (h *Handlers) CreateComment(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "user_id not found in context",
+		})
+	}
+
+	var input dto.CommentCreateDTO
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	cm := dto.ToCommentDomain(postID, userID, &input)
+	if err := h.commentService.Create(context.Background(), cm); err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToCommentDTO(cm))
+}
+
+// GetComments retrieves threads of a post
+// @Summary Get comments
+// @Description Get comment threads of a post with nested replies. Threads list up to 50 replies, replies_cursor of a thread loads the rest
+// @Tags comments
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Threads per page (default 20, max 100)"
+// @Success 200 {object} dto.CommentsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 404 {object} map[string]string "post not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/comments [get]
+func (h *Handlers) GetComments(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	comments, next, err := h.commentService.List(context.Background(), userID, postID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.CommentsPageDTO{
+		Comments:   dto.ToCommentsDTO(comments),
+		NextCursor: next,
+	})
+}
+
+// GetCommentReplies retrieves replies of a thread
+// @Summary Get comment replies
+// @Description Get replies of a top-level comment, oldest first, continuing its replies_cursor. Replies are flat, parent_id places them in the thread
+// @Tags comments
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param comment_id path string true "Top-level comment ID"
+// @Param cursor query string false "Cursor from replies_cursor or previous page"
+// @Param limit query int false "Replies per page (default 20, max 100)"
+// @Success 200 {object} dto.CommentsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 404 {object} map[string]string "post or comment not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/comments/{comment_id}/replies [get]
+func (h *Handlers) GetCommentReplies(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	replies, next, err := h.commentService.ListReplies(context.Background(), userID, c.Params("id"), c.Params("comment_id"), c.Query("cursor"), c.QueryInt("limit", 20))
+	if err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.CommentsPageDTO{
+		Comments:   dto.ToCommentsDTO(replies),
+		NextCursor: next,
+	})
+}
+
+// UpdateComment edits own comment
+// @Summary Update comment
+// @Description Update body of own comment
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param comment_id path string true "Comment ID"
+// @Param comment body dto.CommentUpdateDTO true "Comment data"
+// @Success 200 {object} dto.CommentResponseDTO
+// @Failure 400 {object} map[string]string "invalid body"
+// @Failure 403 {object} map[string]string "not authorized"
+// @Failure 404 {object} map[string]string "comment not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/comments/{comment_id} [put]
+func (h *Handlers) UpdateComment(c *fiber.Ctx) error {
+	commentID := c.Params("comment_id")
+	userID := c.Locals("user_id").(string)
+
+	var input dto.CommentUpdateDTO
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	updated, err := h.commentService.Update(context.Background(), userID, commentID, input.Body)
+	if err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToCommentDTO(updated))
+}
+
+// DeleteComment deletes a comment
+// @Summary Delete comment
+// @Description Delete own comment or a comment on own post, replies are kept
+// @Tags comments
+// @Param id path string true "Post ID"
+// @Param comment_id path string true "Comment ID"
+// @Success 200 {object} map[string]string "successfully deleted"
+// @Failure 403 {object} map[string]string "not authorized"
+// @Failure 404 {object} map[string]string "comment not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/comments/{comment_id} [delete]
+func (h *Handlers) DeleteComment(c *fiber.Ctx) error {
+	commentID := c.Params("comment_id")
+	userID := c.Locals("user_id").(string)
+
+	if err := h.commentService.Delete(context.Background(), userID, commentID); err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully deleted comment",
+	})
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/group"
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/gofiber/fiber/v2"
+)
+
+// groupError maps errors of groups to responses
+func groupError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, group.ErrNotFound), errors.Is(err, group.ErrUserNotFound), errors.Is(err, group.ErrNotMember):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, group.ErrForbidden), errors.Is(err, message.ErrBlocked):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, group.ErrMemberLimit), errors.Is(err, group.ErrLastAdmin):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, group.ErrInvalidTitle),
+		errors.Is(err, group.ErrInvalidRole),
+		errors.Is(err, message.ErrEmptyMessage),
+		errors.Is(err, message.ErrBodyTooLong),
+		errors.Is(err, message.ErrTooManyMedia),
+		errors.Is(err, message.ErrMediaNotAvailable),
+		errors.Is(err, cursor.ErrInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fallback,
+		})
+	}
+}
+
+// GetGroups retrieves groups of the current user
+// @Summary Get groups
+// @Description Get groups with their last message and unread count, latest activity first, with cursor pagination
+// @Tags groups
+// @Produce json
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.GroupsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups [get]
+func (h *Handlers) GetGroups(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	list, next, err := h.groupService.List(context.Background(), userID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if err != nil {
+		return groupError(c, err, "failed to get groups")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.GroupsPageDTO{
+		Groups:     dto.ToGroupsDTO(list),
+		NextCursor: next,
+	})
+}
+
+// CreateGroup creates group with the current user as admin
+// @Summary Create group
+// @Description Create group with a title and members, the creator becomes its admin. Groups are limited in members, users who block each other can't be in one group
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group body dto.GroupCreateDTO true "Group"
+// @Success 201 {object} dto.GroupDTO
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 403 {object} map[string]string "user is blocked"
+// @Failure 404 {object} map[string]string "user not found"
+// @Failure 409 {object} map[string]string "member limit reached"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups [post]
+func (h *Handlers) CreateGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.GroupCreateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	g, err := h.groupService.Create(context.Background(), userID, req.Title, req.Usernames)
+	if err != nil {
+		return groupError(c, err, "failed to create group")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToGroupDTO(g))
+}
+
+// GetGroup retrieves group of the current user
+// @Summary Get group
+// @Description Get group with its members, last message and unread count
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Success 200 {object} dto.GroupDTO
+// @Failure 404 {object} map[string]string "group not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id} [get]
+func (h *Handlers) GetGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	g, err := h.groupService.Get(context.Background(), userID, c.Params("id"))
+	if err != nil {
+		return groupError(c, err, "failed to get group")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToGroupDTO(g))
+}
+
+// UpdateGroup renames group
+// @Summary Rename group
+// @Description Change title of a group, only admins can rename it
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param group body dto.GroupUpdateDTO true "Title"
+// @Success 200 {object} dto.GroupDTO
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 403 {object} map[string]string "not an admin"
+// @Failure 404 {object} map[string]string "group not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id} [put]
+func (h *Handlers) UpdateGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.GroupUpdateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	g, err := h.groupService.Rename(context.Background(), userID, c.Params("id"), req.Title)
+	if err != nil {
+		return groupError(c, err, "failed to update group")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToGroupDTO(g))
+}
+
+// AddGroupMembers adds users to a group
+// @Summary Add group members
+// @Description Add users to a group, only admins can add members. Users who are already members are skipped, users blocking or blocked by a member can't be added
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param members body dto.GroupMembersDTO true "Users to add"
+// @Success 200 {object} dto.GroupDTO
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 403 {object} map[string]string "not an admin or user is blocked"
+// @Failure 404 {object} map[string]string "group or user not found"
+// @Failure 409 {object} map[string]string "member limit reached"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/members [post]
+func (h *Handlers) AddGroupMembers(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.GroupMembersDTO
+	if err := c.BodyParser(&req); err != nil || len(req.Usernames) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	g, err := h.groupService.AddMembers(context.Background(), userID, c.Params("id"), req.Usernames)
+	if err != nil {
+		return groupError(c, err, "failed to add members")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToGroupDTO(g))
+}
+
+// RemoveGroupMember removes user from a group
+// @Summary Remove group member
+// @Description Remove user from a group, only admins can remove others. Removing oneself leaves the group
+// @Tags groups
+// @Param id path string true "Group ID"
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string "successfully removed"
+// @Failure 403 {object} map[string]string "not an admin"
+// @Failure 404 {object} map[string]string "group or member not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/members/{username} [delete]
+func (h *Handlers) RemoveGroupMember(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.groupService.RemoveMember(context.Background(), userID, c.Params("id"), c.Params("username")); err != nil {
+		return groupError(c, err, "failed to remove member")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully removed member",
+	})
+}
+
+// SetGroupMemberRole changes role of a group member
+// @Summary Set group member role
+// @Description Promote member to admin or demote admin to member, only admins can change roles. A group keeps at least one admin
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param username path string true "Username"
+// @Param role body dto.GroupRoleDTO true "Role"
+// @Success 200 {object} dto.GroupDTO
+// @Failure 400 {object} map[string]string "invalid role"
+// @Failure 403 {object} map[string]string "not an admin"
+// @Failure 404 {object} map[string]string "group or member not found"
+// @Failure 409 {object} map[string]string "last admin"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/members/{username} [put]
+func (h *Handlers) SetGroupMemberRole(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.GroupRoleDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	g, err := h.groupService.SetRole(context.Background(), userID, c.Params("id"), c.Params("username"), group.Role(req.Role))
+	if err != nil {
+		return groupError(c, err, "failed to set role")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToGroupDTO(g))
+}
+
+// LeaveGroup removes the current user from a group
+// @Summary Leave group
+// @Description Leave group, when the last admin leaves the longest standing member becomes admin
+// @Tags groups
+// @Param id path string true "Group ID"
+// @Success 200 {object} map[string]string "successfully left"
+// @Failure 404 {object} map[string]string "group not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/leave [post]
+func (h *Handlers) LeaveGroup(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.groupService.Leave(context.Background(), userID, c.Params("id")); err != nil {
+		return groupError(c, err, "failed to leave group")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully left group",
+	})
+}
+
+// GetGroupMessages retrieves messages of a group
+// @Summary Get group messages
+// @Description Get messages including join and leave notices, since the viewer joined, newest first, with cursor pagination
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.GroupMessagesPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 404 {object} map[string]string "group not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/messages [get]
+func (h *Handlers) GetGroupMessages(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	g, messages, next, err := h.groupService.GetMessages(context.Background(), userID, c.Params("id"), c.Query("cursor"), c.QueryInt("limit", 20))
+	if err != nil {
+		return groupError(c, err, "failed to get messages")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.GroupMessagesPageDTO{
+		Messages:   dto.ToGroupMessagesDTO(g, messages),
+		NextCursor: next,
+	})
+}
+
+// SendGroupMessage sends message to a group
+// @Summary Send group message
+// @Description Send message with text and/or up to 4 images uploaded with POST /api/conversations/media
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID"
+// @Param message body dto.MessageCreateDTO true "Message"
+// @Success 201 {object} dto.GroupMessageDTO
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 404 {object} map[string]string "group not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/messages [post]
+func (h *Handlers) SendGroupMessage(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.MessageCreateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	msg := dto.ToMessageDomain(&req)
+	g, err := h.groupService.Send(context.Background(), userID, c.Params("id"), msg)
+	if err != nil {
+		return groupError(c, err, "failed to send message")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToGroupMessageDTO(g, msg))
+}
+
+// MarkGroupRead marks group as read by the current user
+// @Summary Mark group as read
+// @Description Read group up to its latest message
+// @Tags groups
+// @Param id path string true "Group ID"
+// @Success 200 {object} map[string]string "successfully marked"
+// @Failure 404 {object} map[string]string "group not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/groups/{id}/read [post]
+func (h *Handlers) MarkGroupRead(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.groupService.MarkRead(context.Background(), userID, c.Params("id")); err != nil {
+		return groupError(c, err, "failed to mark group as read")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully marked group as read",
+	})
+}
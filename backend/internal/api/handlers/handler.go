@@ -1,16 +1,33 @@
 package handlers
 
 import (
+	"context"
+
 	"github.com/critiq17/critiqal-site/internal/service"
 )
 
 type Handlers struct {
-	userService *service.UserService
-	postService *service.PostService
+	userService     *service.UserService
+	postService     *service.PostService
+	commentService  *service.CommentService
+	mediaService    *service.MediaService
+	bookmarkService *service.BookmarkService
+	trendingService *service.TrendingService
+
+	notificationService *service.NotificationService
+	streamService       *service.StreamService
+	messageService      *service.MessageService
+	groupService        *service.GroupService
+
+	// shutdown is done once the server starts shutting down, open streams
+	// end with it
+	shutdown context.Context
 }
 
-func NewHandlers(userService *service.UserService, postService *service.PostService) *Handlers {
+func NewHandlers(shutdown context.Context, userService *service.UserService, postService *service.PostService, commentService *service.CommentService, mediaService *service.MediaService, bookmarkService *service.BookmarkService, trendingService *service.TrendingService, notificationService *service.NotificationService, streamService *service.StreamService, messageService *service.MessageService, groupService *service.GroupService) *Handlers {
 	return &Handlers{
-		userService: userService, postService: postService,
+		userService: userService, postService: postService, commentService: commentService, mediaService: mediaService, bookmarkService: bookmarkService, trendingService: trendingService,
+		notificationService: notificationService, streamService: streamService, messageService: messageService, groupService: groupService,
+		shutdown: shutdown,
 	}
 }
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"os"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadPostMedia uploads an image which can be attached to a post
+// @Summary Upload post media
+// @Description Upload an image, returned id can be passed in media_ids when creating a post
+// @Tags posts
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Image (jpeg, png, gif, webp)"
+// @Param alt_text formData string false "Image description"
+// @Success 201 {object} dto.MediaDTO
+// @Failure 400 {object} map[string]string "invalid file"
+// @Failure 413 {object} map[string]string "file too large"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/media [post]
+func (h *Handlers) UploadPostMedia(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid file",
+		})
+	}
+
+	m, err := h.mediaService.Upload(c.Context(), userID, file, c.FormValue("alt_text"))
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToMediaDTO(m))
+}
+
+// UploadMessageMedia uploads an image which can be attached to a message
+// @Summary Upload message media
+// @Description Upload a private image, returned id can be passed in media_ids when sending a message to a conversation or group. The image is served by signed URLs only
+// @Tags conversations
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Image (jpeg, png, gif, webp)"
+// @Param alt_text formData string false "Image description"
+// @Success 201 {object} dto.MediaDTO
+// @Failure 400 {object} map[string]string "invalid file"
+// @Failure 413 {object} map[string]string "file too large"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations/media [post]
+func (h *Handlers) UploadMessageMedia(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid file",
+		})
+	}
+
+	m, err := h.mediaService.UploadPrivate(c.Context(), userID, file, c.FormValue("alt_text"))
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToMediaDTO(m))
+}
+
+func uploadError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, media.ErrUnsupportedType), errors.Is(err, media.ErrAltTextTooLong):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "error uploading media",
+		})
+	}
+}
+
+// ServeSignedMedia serves a local file by signed URL
+// @Summary Get signed media
+// @Description Serve a stored file by URL issued for private media, the URL expires
+// @Tags media
+// @Param path path string true "File path"
+// @Param expires query int true "Expiration unix time"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} map[string]string "invalid or expired signature"
+// @Failure 404 {object} map[string]string "file not found"
+// @Router /api/media/signed/{path} [get]
+func (h *Handlers) ServeSignedMedia(c *fiber.Ctx) error {
+	file, err := h.mediaService.ResolveSigned(c.Params("*"), int64(c.QueryInt("expires")), c.Query("signature"))
+	switch {
+	case errors.Is(err, storage.ErrInvalidSignature), errors.Is(err, storage.ErrInvalidPath):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "file not found",
+		})
+	}
+
+	if _, err := os.Stat(file); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "file not found",
+		})
+	}
+
+	// URL stops working after expiration, shared caches must not keep it
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.SendFile(file)
+}
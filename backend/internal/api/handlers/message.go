@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/gofiber/fiber/v2"
+)
+
+// messageError maps errors of conversations to responses
+func messageError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, message.ErrNotFound), errors.Is(err, message.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, message.ErrBlocked):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, message.ErrSelfConversation),
+		errors.Is(err, message.ErrEmptyMessage),
+		errors.Is(err, message.ErrBodyTooLong),
+		errors.Is(err, message.ErrTooManyMedia),
+		errors.Is(err, message.ErrMediaNotAvailable),
+		errors.Is(err, cursor.ErrInvalid):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fallback,
+		})
+	}
+}
+
+// GetConversations retrieves conversations of the current user
+// @Summary Get conversations
+// @Description Get direct conversations with their last message and unread count, latest message first, with cursor pagination
+// @Tags conversations
+// @Produce json
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.ConversationsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations [get]
+func (h *Handlers) GetConversations(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	list, next, err := h.messageService.List(context.Background(), userID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if err != nil {
+		return messageError(c, err, "failed to get conversations")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ConversationsPageDTO{
+		Conversations: dto.ToConversationsDTO(userID, list),
+		NextCursor:    next,
+	})
+}
+
+// OpenConversation starts or retrieves conversation with a user
+// @Summary Open conversation
+// @Description Get direct conversation with a user, it is started if it doesn't exist. There is one conversation per pair of users
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param conversation body dto.ConversationOpenDTO true "User to talk to"
+// @Success 200 {object} dto.ConversationDTO
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 403 {object} map[string]string "user is blocked"
+// @Failure 404 {object} map[string]string "user not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations [post]
+func (h *Handlers) OpenConversation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.ConversationOpenDTO
+	if err := c.BodyParser(&req); err != nil || req.Username == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	conv, err := h.messageService.Open(context.Background(), userID, req.Username)
+	if err != nil {
+		return messageError(c, err, "failed to open conversation")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToConversationDTO(userID, conv))
+}
+
+// GetConversation retrieves conversation of the current user
+// @Summary Get conversation
+// @Description Get conversation with its last message and unread count
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} dto.ConversationDTO
+// @Failure 404 {object} map[string]string "conversation not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations/{id} [get]
+func (h *Handlers) GetConversation(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	conv, err := h.messageService.Get(context.Background(), userID, c.Params("id"))
+	if err != nil {
+		return messageError(c, err, "failed to get conversation")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToConversationDTO(userID, conv))
+}
+
+// GetMessages retrieves messages of a conversation
+// @Summary Get messages
+// @Description Get messages, newest first, with cursor pagination and read receipts
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.MessagesPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 404 {object} map[string]string "conversation not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations/{id}/messages [get]
+func (h *Handlers) GetMessages(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	conv, messages, next, err := h.messageService.GetMessages(context.Background(), userID, c.Params("id"), c.Query("cursor"), c.QueryInt("limit", 20))
+	if err != nil {
+		return messageError(c, err, "failed to get messages")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.MessagesPageDTO{
+		Messages:   dto.ToMessagesDTO(conv, messages),
+		NextCursor: next,
+	})
+}
+
+// SendMessage sends message to a conversation
+// @Summary Send message
+// @Description Send message with text and/or up to 4 images uploaded with POST /api/conversations/media
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message body dto.MessageCreateDTO true "Message"
+// @Success 201 {object} dto.MessageDTO
+// @Failure 400 {object} map[string]string "invalid input"
+// @Failure 403 {object} map[string]string "user is blocked"
+// @Failure 404 {object} map[string]string "conversation not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations/{id}/messages [post]
+func (h *Handlers) SendMessage(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.MessageCreateDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid input",
+		})
+	}
+
+	msg := dto.ToMessageDomain(&req)
+	conv, err := h.messageService.Send(context.Background(), userID, c.Params("id"), msg)
+	if err != nil {
+		return messageError(c, err, "failed to send message")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.ToMessageDTO(conv, msg))
+}
+
+// MarkConversationRead marks conversation as read by the current user
+// @Summary Mark conversation as read
+// @Description Read conversation up to its latest message, the other member sees their messages as read
+// @Tags conversations
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} map[string]string "successfully marked"
+// @Failure 404 {object} map[string]string "conversation not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/conversations/{id}/read [post]
+func (h *Handlers) MarkConversationRead(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.messageService.MarkRead(context.Background(), userID, c.Params("id")); err != nil {
+		return messageError(c, err, "failed to mark conversation as read")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully marked conversation as read",
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetNotifications retrieves notifications of the current user
+// @Summary Get notifications
+// @Description Get notifications, latest activity first, with cursor pagination. Events of the same type about the same post are grouped while unread
+// @Tags notifications
+// @Produce json
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.NotificationsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/notifications [get]
+func (h *Handlers) GetNotifications(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	list, next, unread, err := h.notificationService.List(context.Background(), userID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if errors.Is(err, cursor.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get notifications",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.NotificationsPageDTO{
+		Notifications: dto.ToNotificationsDTO(list),
+		UnreadCount:   unread,
+		NextCursor:    next,
+	})
+}
+
+// MarkNotificationRead marks notification of the current user as read
+// @Summary Mark notification as read
+// @Description Mark notification as read, new events of its group start a new notification
+// @Tags notifications
+// @Param id path string true "Notification ID"
+// @Success 200 {object} map[string]string "successfully marked"
+// @Failure 404 {object} map[string]string "notification not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/notifications/{id}/read [post]
+func (h *Handlers) MarkNotificationRead(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	err := h.notificationService.MarkRead(context.Background(), userID, c.Params("id"))
+	if errors.Is(err, notification.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to mark notification as read",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully marked notification as read",
+	})
+}
+
+// MarkAllNotificationsRead marks all notifications of the current user as read
+// @Summary Mark all notifications as read
+// @Description Mark all unread notifications as read
+// @Tags notifications
+// @Success 200 {object} map[string]string "successfully marked"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/notifications/read [post]
+func (h *Handlers) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.notificationService.MarkAllRead(context.Background(), userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to mark notifications as read",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully marked notifications as read",
+	})
+}
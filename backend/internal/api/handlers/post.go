@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 
 	"github.com/critiq17/critiqal-site/internal/api/dto"
+	domainpost "github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -39,6 +41,25 @@ func (h *Handlers) CreatePost(c *fiber.Ctx) error {
 	post := dto.ToPostDomain(&input)
 
 	err := h.postService.Create(context.Background(), post)
+	if errors.Is(err, domainpost.ErrOriginalNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, domainpost.ErrTooManyMedia) ||
+		errors.Is(err, domainpost.ErrMediaNotAvailable) ||
+		errors.Is(err, domainpost.ErrInvalidStatus) ||
+		errors.Is(err, domainpost.ErrInvalidPublishAt) ||
+		errors.Is(err, domainpost.ErrInvalidVisibility) ||
+		errors.Is(err, domainpost.ErrNotShareable) ||
+		errors.Is(err, domainpost.ErrInvalidPoll) ||
+		errors.Is(err, domainpost.ErrInvalidClosing) ||
+		errors.Is(err, domainpost.ErrContentWarning) ||
+		errors.Is(err, domainpost.ErrSensitiveMedia) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to create post",
@@ -70,7 +91,9 @@ func (h *Handlers) GetPost(ctx *fiber.Ctx) error {
 		})
 	}
 
-	post, err := h.postService.Get(context.Background(), postID)
+	userID := ctx.Locals("user_id").(string)
+
+	post, err := h.postService.Get(context.Background(), userID, postID)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "post not found",
@@ -82,21 +105,21 @@ func (h *Handlers) GetPost(ctx *fiber.Ctx) error {
 
 // UpdatePost updates an existing post
 // @Summary Update post
-// @Description Update post by ID
+// @Description Update post by ID, drafts can be scheduled or published with status and publish_at
 // @Tags posts
 // @Accept json
 // @Produce json
-// @Param post_id path string true "Post ID"
+// @Param id path string true "Post ID"
 // @Param post body dto.PostUpdateDTO true "Updated post data"
 // @Success 200 {object} map[string]string "successfully updated"
 // @Failure 400 {object} map[string]string "invalid body"
-// @Failure 403 {object} map[string]string "not authorized"
+// @Failure 403 {object} map[string]string "not authorized or edit window closed"
 // @Failure 404 {object} map[string]string "post not found"
 // @Failure 500 {object} map[string]string "server error"
-// @Router /api/posts/{post_id} [put]
+// @Router /api/posts/{id} [put]
 func (h *Handlers) UpdatePost(ctx *fiber.Ctx) error {
 
-	postID := ctx.Params("post_id")
+	postID := ctx.Params("id")
 	userID := ctx.Locals("user_id").(string)
 
 	var req dto.PostUpdateDTO
@@ -106,7 +129,7 @@ func (h *Handlers) UpdatePost(ctx *fiber.Ctx) error {
 		})
 	}
 
-	existingPost, err := h.postService.Get(context.Background(), postID)
+	existingPost, err := h.postService.GetForOwner(context.Background(), userID, postID)
 	if err != nil {
 		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "post not found",
@@ -119,7 +142,23 @@ func (h *Handlers) UpdatePost(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.postService.Update(context.Background(), postID, dto.ToPostDomainFromUpdateDTO(&req)); err != nil {
+	if err := h.postService.Update(context.Background(), userID, postID, dto.ToPostDomainFromUpdateDTO(&req)); err != nil {
+		if errors.Is(err, domainpost.ErrEditWindowClosed) {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, domainpost.ErrNotEditable) ||
+			errors.Is(err, domainpost.ErrAlreadyPublished) ||
+			errors.Is(err, domainpost.ErrInvalidStatus) ||
+			errors.Is(err, domainpost.ErrInvalidPublishAt) ||
+			errors.Is(err, domainpost.ErrInvalidClosing) ||
+			errors.Is(err, domainpost.ErrContentWarning) ||
+			errors.Is(err, domainpost.ErrSensitiveMedia) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -130,21 +169,50 @@ func (h *Handlers) UpdatePost(ctx *fiber.Ctx) error {
 	})
 }
 
+// GetPostRevisions retrieves previous versions of an edited post
+// @Summary Get post revisions
+// @Description Get previous versions of a post, newest first
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {array} dto.PostRevisionDTO
+// @Failure 404 {object} map[string]string "post not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/revisions [get]
+func (h *Handlers) GetPostRevisions(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	revisions, err := h.postService.GetRevisions(context.Background(), userID, postID)
+	if errors.Is(err, domainpost.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get revisions",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToPostRevisionsDTO(revisions))
+}
+
 // DeletePost deletes a post
 // @Summary Delete post
 // @Description Delete post by ID
 // @Tags posts
-// @Param post_id path string true "Post ID"
+// @Param id path string true "Post ID"
 // @Success 200 {object} map[string]string "successfully deleted"
 // @Failure 403 {object} map[string]string "not authorized"
 // @Failure 404 {object} map[string]string "post not found"
 // @Failure 500 {object} map[string]string "server error"
-// @Router /api/posts/{post_id} [delete]
+// @Router /api/posts/{id} [delete]
 func (h *Handlers) DeletePost(ctx *fiber.Ctx) error {
-	postID := ctx.Params("post_id")
+	postID := ctx.Params("id")
 	userID := ctx.Locals("user_id").(string)
 
-	existingPost, err := h.postService.Get(context.Background(), postID)
+	existingPost, err := h.postService.GetForOwner(context.Background(), userID, postID)
 	if err != nil {
 		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "post not found",
@@ -170,7 +238,7 @@ func (h *Handlers) DeletePost(ctx *fiber.Ctx) error {
 
 // GetPostsByUserID retrieves all posts by a specific user
 // @Summary Get user posts
-// @Description Get all posts by username
+// @Description Get all posts by username, pinned posts first
 // @Tags posts
 // @Produce json
 // @Param username path string true "Username"
@@ -189,7 +257,9 @@ func (h *Handlers) GetPostsByUserName(c *fiber.Ctx) error {
 		})
 	}
 
-	posts, err := h.postService.GetPostsByUserID(context.Background(), user.ID)
+	viewerID := c.Locals("user_id").(string)
+
+	posts, err := h.postService.GetPostsByUserID(context.Background(), viewerID, user.ID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get posts",
@@ -214,25 +284,302 @@ func (h *Handlers) GetRecentPosts(c *fiber.Ctx) error {
 		limit = queryLimit
 	}
 
-	posts, err := h.postService.GetRecentPosts(context.Background(), limit)
+	userID := c.Locals("user_id").(string)
+
+	posts, err := h.postService.GetRecentPosts(context.Background(), userID, limit)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get recent posts",
 		})
 	}
 
-	fmt.Printf("Found %d posts\n", len(posts))
-	for i, p := range posts {
-		fmt.Printf("Post %d: ID=%s, Owner.Username=%s, Owner.ID=%s\n",
-			i, p.ID, p.Owner.Username, p.Owner.ID)
+	return c.Status(fiber.StatusOK).JSON(dto.ToPostsDTO(posts))
+}
+
+// Repost boosts someone's post
+// @Summary Repost
+// @Description Repost a post, reposting the same post twice returns the existing repost
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} dto.PostResponseDTO
+// @Failure 404 {object} map[string]string "post not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/repost [post]
+func (h *Handlers) Repost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	repost, err := h.postService.Repost(context.Background(), userID, postID)
+	if errors.Is(err, domainpost.ErrOriginalNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, domainpost.ErrNotShareable) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to repost",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToPostDTO(repost))
+}
+
+// Unrepost removes own repost
+// @Summary Undo repost
+// @Description Remove own repost of a post
+// @Tags posts
+// @Param id path string true "Post ID"
+// @Success 200 {object} map[string]string "successfully removed"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/repost [delete]
+func (h *Handlers) Unrepost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	if err := h.postService.Unrepost(context.Background(), userID, postID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to remove repost",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully removed repost",
+	})
+}
+
+// PinPost pins own post to the top of the profile
+// @Summary Pin post
+// @Description Pin own published post to the top of the profile, up to 3 posts can be pinned
+// @Tags posts
+// @Param id path string true "Post ID"
+// @Success 200 {object} map[string]string "successfully pinned"
+// @Failure 400 {object} map[string]string "too many pinned posts"
+// @Failure 404 {object} map[string]string "post not found"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/pin [post]
+func (h *Handlers) PinPost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	err := h.postService.Pin(context.Background(), userID, postID)
+	switch {
+	case errors.Is(err, domainpost.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domainpost.ErrTooManyPinned):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to pin post",
+		})
 	}
 
-	dtos := dto.ToPostsDTO(posts)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully pinned post",
+	})
+}
+
+// UnpinPost removes own post from the top of the profile
+// @Summary Unpin post
+// @Description Unpin own post
+// @Tags posts
+// @Param id path string true "Post ID"
+// @Success 200 {object} map[string]string "successfully unpinned"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/pin [delete]
+func (h *Handlers) UnpinPost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
 
-	fmt.Printf("Sending %d DTOs\n", len(dtos))
-	if len(dtos) > 0 {
-		fmt.Printf("First DTO: %+v\n", dtos[0])
+	if err := h.postService.Unpin(context.Background(), userID, postID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to unpin post",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully unpinned post",
+	})
+}
+
+// GetMyMentions retrieves posts which mention current user
+// @Summary Get my mentions
+// @Description Get posts of other users which mention current user, newest first
+// @Tags posts
+// @Produce json
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Posts per page (default 20, max 100)"
+// @Success 200 {object} dto.PostsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/mentions [get]
+func (h *Handlers) GetMyMentions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	posts, next, err := h.postService.GetMentions(context.Background(), userID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if errors.Is(err, cursor.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get mentions",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.PostsPageDTO{
+		Posts:      dto.ToPostsDTO(posts),
+		NextCursor: next,
+	})
+}
+
+// GetMyDrafts retrieves drafts and scheduled posts of current user
+// @Summary Get my drafts
+// @Description Get own drafts and scheduled posts, newest first
+// @Tags posts
+// @Produce json
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Posts per page (default 20, max 100)"
+// @Success 200 {object} dto.PostsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/drafts [get]
+func (h *Handlers) GetMyDrafts(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	posts, next, err := h.postService.GetDrafts(context.Background(), userID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if errors.Is(err, cursor.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get drafts",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.PostsPageDTO{
+		Posts:      dto.ToPostsDTO(posts),
+		NextCursor: next,
+	})
+}
+
+// VotePoll votes in poll of a post
+// @Summary Vote in poll
+// @Description Vote in poll of a post, a user votes only once. Tallies are returned once the caller voted or the poll closed
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path string true "Post ID"
+// @Param vote body dto.PollVoteDTO true "Picked options"
+// @Success 200 {object} dto.PostResponseDTO
+// @Failure 400 {object} map[string]string "invalid choice or poll closed"
+// @Failure 404 {object} map[string]string "post or poll not found"
+// @Failure 409 {object} map[string]string "already voted"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/poll/votes [post]
+func (h *Handlers) VotePoll(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	var req dto.PollVoteDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	p, err := h.postService.Vote(context.Background(), userID, postID, req.Choices)
+	switch {
+	case errors.Is(err, domainpost.ErrNotFound), errors.Is(err, domainpost.ErrNoPoll):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domainpost.ErrInvalidChoice), errors.Is(err, domainpost.ErrPollClosed):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, domainpost.ErrAlreadyVoted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to vote",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToPostDTO(p))
+}
+
+// GetMyTrash retrieves deleted posts of the current user which can still be restored
+// @Summary Get my trash
+// @Description Get own deleted posts within the retention window, most recently deleted first, with cursor pagination
+// @Tags posts
+// @Produce json
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} dto.PostsPageDTO
+// @Failure 400 {object} map[string]string "invalid cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/users/me/trash [get]
+func (h *Handlers) GetMyTrash(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	posts, next, err := h.postService.GetTrash(context.Background(), userID, c.Query("cursor"), c.QueryInt("limit", 20))
+	if errors.Is(err, cursor.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get trash",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.PostsPageDTO{
+		Posts:      dto.ToPostsDTO(posts),
+		NextCursor: next,
+	})
+}
+
+// RestorePost brings back own deleted post
+// @Summary Restore post
+// @Description Restore own deleted post within the retention window, restored posts are not pinned
+// @Tags posts
+// @Produce json
+// @Param id path string true "Post ID"
+// @Success 200 {object} dto.PostResponseDTO
+// @Failure 404 {object} map[string]string "post not found in trash"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/{id}/restore [post]
+func (h *Handlers) RestorePost(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	userID := c.Locals("user_id").(string)
+
+	p, err := h.postService.Restore(context.Background(), userID, postID)
+	if errors.Is(err, domainpost.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to restore post",
+		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(dtos)
+	return c.Status(fiber.StatusOK).JSON(dto.ToPostDTO(p))
 }
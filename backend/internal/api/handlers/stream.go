@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Stream pushes real-time updates to the current user as Server-Sent Events
+// @Summary Stream updates
+// @Description Server-Sent Events stream. Event "post" carries a new post of a followed user (dto.PostResponseDTO), "counts" changed comments and reposts counts (dto.PostCountsDTO), "notification" a new notification (dto.NotificationEventDTO), "message" a new direct or group message and "read" a conversation or group read by another member (dto.MessageEventDTO, conversation_id is the group ID for groups). Browsers authenticate with the access_token cookie
+// @Tags stream
+// @Produce text/event-stream
+// @Success 200 {string} string "event stream"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/stream [get]
+func (h *Handlers) Stream(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	ctx, cancel := context.WithCancel(h.shutdown)
+	updates, err := h.streamService.Subscribe(ctx, userID)
+	if err != nil {
+		cancel()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to open stream",
+		})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	// nginx buffers responses by default
+	c.Set("X-Accel-Buffering", "no")
+
+	heartbeat := h.streamService.Heartbeat()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		// writes fail only on flush once the client is gone
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				if err := writeStreamEvent(w, update); err != nil {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeStreamEvent(w *bufio.Writer, update service.StreamUpdate) error {
+	var data interface{}
+	switch update.Type {
+	case service.EventPost:
+		data = dto.ToPostDTO(update.Post)
+	case service.EventCounts:
+		data = dto.PostCountsDTO{
+			PostID:        update.Post.ID,
+			CommentsCount: update.Post.CommentsCount,
+			RepostsCount:  update.Post.RepostsCount,
+		}
+	case service.EventNotification:
+		data = dto.NotificationEventDTO{
+			Type:   update.NotificationType,
+			PostID: update.PostID,
+		}
+	case service.EventMessage, service.EventRead:
+		data = dto.MessageEventDTO{
+			ConversationID: update.ConversationID,
+			MessageID:      update.MessageID,
+		}
+	default:
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, payload)
+	return err
+}
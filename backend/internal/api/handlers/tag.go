@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTagPosts retrieves posts tagged with a hashtag
+// @Summary Get tag posts
+// @Description Get posts tagged with a hashtag, newest first
+// @Tags tags
+// @Produce json
+// @Param tag path string true "Hashtag without #"
+// @Param cursor query string false "Cursor from previous page"
+// @Param limit query int false "Posts per page (default 20, max 100)"
+// @Success 200 {object} dto.PostsPageDTO
+// @Failure 400 {object} map[string]string "invalid tag or cursor"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/tags/{tag}/posts [get]
+func (h *Handlers) GetTagPosts(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	posts, next, err := h.postService.GetPostsByTag(context.Background(), userID, c.Params("tag"), c.Query("cursor"), c.QueryInt("limit", 20))
+	if errors.Is(err, post.ErrInvalidTag) || errors.Is(err, cursor.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get posts",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.PostsPageDTO{
+		Posts:      dto.ToPostsDTO(posts),
+		NextCursor: next,
+	})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/trending"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTrendingPosts retrieves posts ranked by recent engagement
+// @Summary Get trending posts
+// @Description Get public posts ranked by time-decayed comments and reposts, the ranking is recomputed periodically
+// @Tags posts
+// @Produce json
+// @Param limit query int false "Number of posts (default 20, max 100)"
+// @Success 200 {array} dto.PostResponseDTO
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/posts/trending [get]
+func (h *Handlers) GetTrendingPosts(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	posts, err := h.postService.GetTrending(context.Background(), userID, c.QueryInt("limit", 20))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get trending posts",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToPostsDTO(posts))
+}
+
+// GetTrendingTags retrieves hashtags used by most authors within a window
+// @Summary Get trending hashtags
+// @Description Get hashtags ranked by distinct authors, then posts, within a sliding window. Windows are configured, e.g. 1h, 24h and 168h
+// @Tags tags
+// @Produce json
+// @Param window query string false "Window, e.g. 24h (default first configured window)"
+// @Param limit query int false "Number of tags"
+// @Success 200 {array} dto.TrendingTagDTO
+// @Failure 400 {object} map[string]string "unknown window"
+// @Failure 500 {object} map[string]string "server error"
+// @Router /api/tags/trending [get]
+func (h *Handlers) GetTrendingTags(c *fiber.Ctx) error {
+	var window time.Duration
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": trending.ErrInvalidWindow.Error(),
+			})
+		}
+		window = d
+	} else if windows := h.trendingService.Windows(); len(windows) > 0 {
+		window = windows[0]
+	}
+
+	tags, err := h.trendingService.GetTags(context.Background(), window, c.QueryInt("limit", 0))
+	if errors.Is(err, trending.ErrInvalidWindow) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to get trending tags",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(dto.ToTrendingTagsDTO(tags))
+}
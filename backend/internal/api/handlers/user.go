@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/critiq17/critiqal-site/internal/api/dto"
+	"github.com/critiq17/critiqal-site/internal/domain/media"
 	"github.com/critiq17/critiqal-site/internal/domain/user"
 	"github.com/gofiber/fiber/v2"
 )
@@ -139,7 +141,16 @@ func (h *Handlers) UploadPhoto(c *fiber.Ctx) error {
 	}
 
 	url, err := h.userService.UploadUserPhoto(c.Context(), username, file)
-	if err != nil {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, media.ErrUnsupportedType):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "error uploading photo",
 		})
@@ -179,3 +190,168 @@ func (h *Handlers) GetMe(c *fiber.Ctx) error {
 
 	return c.JSON(dto.ToUserApi(user))
 }
+
+// FollowUser makes current user follow another user
+// @Summary Follow user
+// @Description Follow a user, followers see their followers-only posts
+// @Tags users
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string "successfully followed"
+// @Failure 400 {object} map[string]string "cannot follow yourself"
+// @Failure 404 {object} map[string]string "user not found"
+// @Router /api/users/{username}/follow [post]
+func (h *Handlers) FollowUser(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	err := h.userService.Follow(userID, c.Params("username"))
+	switch {
+	case errors.Is(err, user.ErrCannotFollowSelf):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully followed user",
+	})
+}
+
+// UnfollowUser makes current user stop following another user
+// @Summary Unfollow user
+// @Description Stop following a user
+// @Tags users
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string "successfully unfollowed"
+// @Failure 404 {object} map[string]string "user not found"
+// @Router /api/users/{username}/follow [delete]
+func (h *Handlers) UnfollowUser(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.userService.Unfollow(userID, c.Params("username")); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully unfollowed user",
+	})
+}
+
+// BlockUser makes current user block another user
+// @Summary Block user
+// @Description Block a user, neither of you can start a conversation or send direct messages to the other or add them to a group
+// @Tags users
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string "successfully blocked"
+// @Failure 400 {object} map[string]string "cannot block yourself"
+// @Failure 404 {object} map[string]string "user not found"
+// @Router /api/users/{username}/block [post]
+func (h *Handlers) BlockUser(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	err := h.userService.Block(userID, c.Params("username"))
+	switch {
+	case errors.Is(err, user.ErrCannotBlockSelf):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully blocked user",
+	})
+}
+
+// UnblockUser removes block of another user by current user
+// @Summary Unblock user
+// @Description Remove a block, a block by the other user stays in place
+// @Tags users
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string "successfully unblocked"
+// @Failure 404 {object} map[string]string "user not found"
+// @Router /api/users/{username}/block [delete]
+func (h *Handlers) UnblockUser(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := h.userService.Unblock(userID, c.Params("username")); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "successfully unblocked user",
+	})
+}
+
+// GetMyPreferences retrieves settings of the current user
+// @Summary Get my preferences
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.PreferencesResponseDTO
+// @Failure 404 {object} map[string]string "user not found"
+// @Router /api/users/me/preferences [get]
+func (h *Handlers) GetMyPreferences(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	u, err := h.userService.GetByID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	return c.JSON(dto.ToPreferencesDTO(u.Preferences))
+}
+
+// UpdateMyPreferences changes settings of the current user
+// @Summary Update my preferences
+// @Description Update settings, omitted fields are kept. expand_sensitive_media shows sensitive media in feeds without a click
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param preferences body dto.PreferencesDTO true "Preferences"
+// @Success 200 {object} dto.PreferencesResponseDTO
+// @Failure 400 {object} map[string]string "invalid request body"
+// @Failure 404 {object} map[string]string "user not found"
+// @Router /api/users/me/preferences [put]
+func (h *Handlers) UpdateMyPreferences(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req dto.PreferencesDTO
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	u, err := h.userService.GetByID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	prefs := u.Preferences
+	if req.ExpandSensitiveMedia != nil {
+		prefs.ExpandSensitiveMedia = *req.ExpandSensitiveMedia
+	}
+
+	u, err = h.userService.UpdatePreferences(userID, prefs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to update preferences",
+		})
+	}
+
+	return c.JSON(dto.ToPreferencesDTO(u.Preferences))
+}
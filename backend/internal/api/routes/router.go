@@ -1,9 +1,12 @@
 package routes
 
 import (
+	"strings"
+
 	//_ "github.com/critiq17/critiqal-site/backend/docs"
 	_ "github.com/critiq17/critiqal-site/docs"
 	"github.com/critiq17/critiqal-site/internal/api/handlers"
+	"github.com/critiq17/critiqal-site/internal/storage"
 	"github.com/gofiber/fiber/v2"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 )
@@ -14,10 +17,13 @@ func InitRoutes(app *fiber.App, handlers *handlers.Handlers) {
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
 	// endpoint where saves all users profile picture
-	app.Static("/uploads", "./uploads")
+	serveUploads(app, storage.DefaultLocalPath)
 
 	api := app.Group("/api")
 
+	// signed URLs of private media, signature is checked instead of token
+	api.Get("/media/signed/*", handlers.ServeSignedMedia)
+
 	// login, register
 	auth := api.Group("/auth")
 	{
@@ -43,9 +49,37 @@ func InitRoutes(app *fiber.App, handlers *handlers.Handlers) {
 		// upload photo
 		users.Post("/:username/photo", handlers.UploadPhoto)
 
+		// followers see followers-only posts
+		users.Post("/:username/follow", handlers.FollowUser)
+		users.Delete("/:username/follow", handlers.UnfollowUser)
+
+		// blocked users can't message each other
+		users.Post("/:username/block", handlers.BlockUser)
+		users.Delete("/:username/block", handlers.UnblockUser)
+
 		// retrieves full user information, without password, id
 		users.Get("/me", handlers.GetMe)
 
+		// posts which mention current user
+		users.Get("/me/mentions", handlers.GetMyMentions)
+
+		// own drafts and scheduled posts
+		users.Get("/me/drafts", handlers.GetMyDrafts)
+
+		// own deleted posts which can still be restored
+		users.Get("/me/trash", handlers.GetMyTrash)
+
+		// display settings, e.g. expanding sensitive media in feeds
+		users.Get("/me/preferences", handlers.GetMyPreferences)
+		users.Put("/me/preferences", handlers.UpdateMyPreferences)
+
+		// bookmarked posts, optionally grouped into collections
+		users.Get("/me/bookmarks", handlers.GetMyBookmarks)
+		users.Get("/me/bookmarks/collections", handlers.GetBookmarkCollections)
+		users.Post("/me/bookmarks/collections", handlers.CreateBookmarkCollection)
+		users.Put("/me/bookmarks/collections/:collection_id", handlers.RenameBookmarkCollection)
+		users.Delete("/me/bookmarks/collections/:collection_id", handlers.DeleteBookmarkCollection)
+
 	}
 
 	posts := api.Group("/posts", handlers.UserIdentity)
@@ -53,15 +87,106 @@ func InitRoutes(app *fiber.App, handlers *handlers.Handlers) {
 		// CRUD
 		posts.Post("/", handlers.CreatePost)
 
+		// upload image to attach with media_ids on create
+		posts.Post("/media", handlers.UploadPostMedia)
+
 		// retrieves 50 recents posts, by created_at
 		posts.Get("/recent", handlers.GetRecentPosts)
 
+		// ranked by time-decayed engagement, recomputed periodically
+		posts.Get("/trending", handlers.GetTrendingPosts)
+
 		posts.Get("/:id", handlers.GetPost)
 		posts.Put("/:id", handlers.UpdatePost)
 		posts.Delete("/:id", handlers.DeletePost)
 
+		posts.Post("/:id/restore", handlers.RestorePost)
+
+		// previous versions of edited post
+		posts.Get("/:id/revisions", handlers.GetPostRevisions)
+
 		// retrieves all posts by username
 		posts.Get("/users/:username", handlers.GetPostsByUserName)
+
+		// pinned posts go first on profile
+		posts.Post("/:id/pin", handlers.PinPost)
+		posts.Delete("/:id/pin", handlers.UnpinPost)
+
+		// one vote per user, tallies are hidden until voting or closing
+		posts.Post("/:id/poll/votes", handlers.VotePoll)
+
+		posts.Post("/:id/bookmark", handlers.BookmarkPost)
+		posts.Delete("/:id/bookmark", handlers.UnbookmarkPost)
+
+		// reposts, quote posts are created with quote_of_id
+		posts.Post("/:id/repost", handlers.Repost)
+		posts.Delete("/:id/repost", handlers.Unrepost)
+
+		// comments, threads with nested replies
+		posts.Get("/:id/comments", handlers.GetComments)
+		posts.Get("/:id/comments/:comment_id/replies", handlers.GetCommentReplies)
+		posts.Post("/:id/comments", handlers.CreateComment)
+		posts.Put("/:id/comments/:comment_id", handlers.UpdateComment)
+		posts.Delete("/:id/comments/:comment_id", handlers.DeleteComment)
+	}
+
+	// posts by hashtag
+	tags := api.Group("/tags", handlers.UserIdentity)
+	{
+		tags.Get("/trending", handlers.GetTrendingTags)
+		tags.Get("/:tag/posts", handlers.GetTagPosts)
+	}
+
+	// follows, comments, mentions and reposts, grouped while unread
+	notifications := api.Group("/notifications", handlers.UserIdentity)
+	{
+		notifications.Get("/", handlers.GetNotifications)
+		notifications.Post("/read", handlers.MarkAllNotificationsRead)
+		notifications.Post("/:id/read", handlers.MarkNotificationRead)
+	}
+
+	// direct messages, one conversation per pair of users
+	conversations := api.Group("/conversations", handlers.UserIdentity)
+	{
+		conversations.Get("/", handlers.GetConversations)
+		conversations.Post("/", handlers.OpenConversation)
+		conversations.Post("/media", handlers.UploadMessageMedia)
+		conversations.Get("/:id", handlers.GetConversation)
+		conversations.Get("/:id/messages", handlers.GetMessages)
+		conversations.Post("/:id/messages", handlers.SendMessage)
+		conversations.Post("/:id/read", handlers.MarkConversationRead)
+	}
+
+	// group conversations, admins manage title and members
+	groups := api.Group("/groups", handlers.UserIdentity)
+	{
+		groups.Get("/", handlers.GetGroups)
+		groups.Post("/", handlers.CreateGroup)
+		groups.Get("/:id", handlers.GetGroup)
+		groups.Put("/:id", handlers.UpdateGroup)
+		groups.Post("/:id/members", handlers.AddGroupMembers)
+		groups.Delete("/:id/members/:username", handlers.RemoveGroupMember)
+		groups.Put("/:id/members/:username", handlers.SetGroupMemberRole)
+		groups.Post("/:id/leave", handlers.LeaveGroup)
+		groups.Get("/:id/messages", handlers.GetGroupMessages)
+		groups.Post("/:id/messages", handlers.SendGroupMessage)
+		groups.Post("/:id/read", handlers.MarkGroupRead)
 	}
 
+	// Server-Sent Events with new posts of followed users, counts and notifications
+	api.Get("/stream", handlers.UserIdentity, handlers.Stream)
+
+}
+
+// serveUploads serves public files of local storage. Private files are
+// stored outside of root and available only by signed URLs, files left
+// under root by older versions are still skipped. The check uses the
+// normalized path, so "//", escapes and case of the route don't bypass it
+func serveUploads(app *fiber.App, root string) {
+	private := "/uploads/" + storage.PrivatePrefix
+	app.Static("/uploads", root, fiber.Static{
+		Next: func(c *fiber.Ctx) bool {
+			return strings.HasPrefix(strings.ToLower(string(c.Context().Path())), private)
+		},
+	})
 }
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestServeUploadsHidesPrivateFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "private"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "private", "x"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "public.txt"), []byte("public"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	serveUploads(app, root)
+
+	for _, path := range []string{
+		"/uploads/private/x",
+		"/uploads//private/x",
+		"/uploads/%70rivate/x",
+		"/Uploads/private/x",
+		"/uploads/./private/x",
+		"/uploads/PRIVATE/../private/x",
+	} {
+		res, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		if res.StatusCode == fiber.StatusOK || string(body) == "secret" {
+			t.Errorf("GET %s = %d %q, private file must not be served", path, res.StatusCode, body)
+		}
+	}
+
+	res, err := app.Test(httptest.NewRequest("GET", "/uploads/public.txt", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != fiber.StatusOK || string(body) != "public" {
+		t.Errorf("GET /uploads/public.txt = %d %q, want 200 public", res.StatusCode, body)
+	}
+}
@@ -1,15 +1,21 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/critiq17/critiqal-site/config"
 	"github.com/critiq17/critiqal-site/internal/api/handlers"
 	"github.com/critiq17/critiqal-site/internal/api/routes"
 	"github.com/critiq17/critiqal-site/internal/db"
+	"github.com/critiq17/critiqal-site/internal/domain/trending"
 	"github.com/critiq17/critiqal-site/internal/storage"
 	"github.com/critiq17/critiqal-site/pkg/logger"
+	"github.com/critiq17/critiqal-site/pkg/pubsub"
+	"github.com/critiq17/critiqal-site/pkg/unfurl"
 
 	"github.com/critiq17/critiqal-site/internal/repository"
 	"github.com/critiq17/critiqal-site/internal/service"
@@ -17,21 +23,93 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
 
-func SetupApp() (*fiber.App, error) {
+// SetupApp wires the application. Background workers run until ctx is
+// done, the returned func waits for them to finish their last work.
+// Streams are closed once shutdown is done, so they don't hold up the
+// server shutdown
+func SetupApp(ctx, shutdown context.Context) (*fiber.App, func(), error) {
 	log := logger.New(logger.DEBUG)
 
 	cfg := config.LoadConfig()
 
 	db := db.Must(&cfg.DatabaseConfig)
-	storage, err := storage.NewStorageFromEnv()
-
-	if err != nil || storage == nil {
-		log.Warn("Falling back to local storage...")
+	fileStorage, err := storage.NewStorageFromEnvOrLocal()
+	if err != nil {
+		log.Warn("Falling back to local storage...", fmt.Sprint(err))
 	}
 	userRepo := repository.NewRepository(db.DB)
 	postRepo := repository.NewPostRepository(db.DB)
-	userService := service.NewUserService(userRepo, storage)
-	postService := service.NewPostService(postRepo)
+	commentRepo := repository.NewCommentRepository(db.DB)
+	mediaRepo := repository.NewMediaRepository(db.DB)
+	bookmarkRepo := repository.NewBookmarkRepository(db.DB)
+	previewRepo := repository.NewPreviewRepository(db.DB)
+	trendingRepo := repository.NewTrendingRepository(db.DB)
+	notificationRepo := repository.NewNotificationRepository(db.DB)
+	messageRepo := repository.NewMessageRepository(db.DB)
+	groupRepo := repository.NewGroupRepository(db.DB)
+
+	var ps pubsub.PubSub = pubsub.NewMemory()
+	if cfg.Realtime.PubSub == "postgres" {
+		sqlDB, err := db.DB.DB()
+		if err != nil {
+			return nil, nil, err
+		}
+		pg := pubsub.NewPostgres(sqlDB, cfg.DatabaseConfig.DSN(), cfg.Realtime.Channel)
+		go pg.Run(ctx, func(err error) {
+			log.Error("listening for events failed", err.Error())
+		})
+		ps = pg
+	}
+	events := service.NewEvents(ps, log)
+
+	notificationService := service.NewNotificationService(notificationRepo, events, log)
+	userService := service.NewUserService(userRepo, mediaRepo, fileStorage, notificationService, events)
+	unfurler := unfurl.New(unfurl.Config{
+		Timeout:      cfg.Previews.Timeout,
+		MaxBodySize:  int64(cfg.Previews.MaxBodySize),
+		MaxRedirects: cfg.Previews.MaxRedirects,
+	})
+	previewService := service.NewPreviewService(previewRepo, unfurler, log, cfg.Previews.TTL, cfg.Previews.Timeout)
+	viewCounter := service.NewViewCounter(postRepo, log, cfg.Posts.ViewsFlushInterval, cfg.Posts.ViewsWindow)
+	postService := service.NewPostService(postRepo, userRepo, mediaRepo, fileStorage, bookmarkRepo, previewService, viewCounter, notificationService, events, cfg.Posts.EditWindow, cfg.Posts.TrashRetention)
+	mediaService := service.NewMediaService(mediaRepo, fileStorage)
+	commentService := service.NewCommentService(commentRepo, postRepo, notificationService, events)
+	bookmarkService := service.NewBookmarkService(bookmarkRepo, postRepo)
+	trendingService := service.NewTrendingService(trendingRepo, trending.Params{
+		HalfLife:      cfg.Trending.HalfLife,
+		Lookback:      cfg.Trending.Lookback,
+		CommentWeight: cfg.Trending.CommentWeight,
+		RepostWeight:  cfg.Trending.RepostWeight,
+		MaxPosts:      cfg.Trending.MaxPosts,
+		MaxTags:       cfg.Trending.MaxTags,
+		TagWindows:    cfg.Trending.TagWindows,
+	}, log, cfg.Trending.Interval)
+
+	messageService := service.NewMessageService(messageRepo, userRepo, mediaRepo, fileStorage, events)
+	groupService := service.NewGroupService(groupRepo, messageRepo, userRepo, mediaRepo, fileStorage, events, cfg.Groups.MaxMembers)
+	streamService := service.NewStreamService(ps, postService, userRepo, log, cfg.Realtime.Heartbeat)
+
+	var workers sync.WaitGroup
+	run := func(worker func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(ctx)
+		}()
+	}
+
+	mediaGC := service.NewMediaGC(mediaRepo, fileStorage, log, cfg.Media.GCInterval, cfg.Media.GCGracePeriod)
+	run(mediaGC.Run)
+
+	postScheduler := service.NewPostScheduler(postService, log, cfg.Posts.SchedulerInterval)
+	run(postScheduler.Run)
+
+	trashPurger := service.NewTrashPurger(postService, log, cfg.Posts.PurgeInterval)
+	run(trashPurger.Run)
+
+	run(trendingService.Run)
+
+	run(viewCounter.Run)
 
 	app := fiber.New()
 
@@ -64,11 +142,11 @@ func SetupApp() (*fiber.App, error) {
 		AllowCredentials: true,
 	}))
 
-	handlers := handlers.NewHandlers(userService, postService)
+	handlers := handlers.NewHandlers(shutdown, userService, postService, commentService, mediaService, bookmarkService, trendingService, notificationService, streamService, messageService, groupService)
 	routes.InitRoutes(app, handlers)
 
 	log.Info("Success init db, handlers, and more")
 	db.Debug()
 
-	return app, nil
+	return app, workers.Wait, nil
 }
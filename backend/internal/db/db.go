@@ -5,7 +5,6 @@ import (
 	"log"
 
 	"github.com/critiq17/critiqal-site/config"
-	"github.com/critiq17/critiqal-site/internal/domain/user"
 	"github.com/critiq17/critiqal-site/internal/repository"
 	pgdriver "gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -46,7 +45,7 @@ func setupDB(cfg *config.DatabaseConfig) (*DB, error) {
 // migrating models for DB
 func migrate(db *DB) error {
 
-	if err := db.AutoMigrate(&user.User{}, &repository.PostModel{}); err != nil {
+	if err := db.AutoMigrate(&repository.User{}, &repository.PostModel{}, &repository.CommentModel{}, &repository.PostTagModel{}, &repository.MentionModel{}, &repository.MediaModel{}, &repository.BlobModel{}, &repository.PostRevisionModel{}, &repository.FollowModel{}, &repository.BlockModel{}, &repository.BookmarkModel{}, &repository.BookmarkCollectionModel{}, &repository.PollModel{}, &repository.PollOptionModel{}, &repository.PollBallotModel{}, &repository.PollChoiceModel{}, &repository.LinkPreviewModel{}, &repository.TrendingPostModel{}, &repository.TrendingTagModel{}, &repository.PostViewModel{}, &repository.NotificationModel{}, &repository.NotificationActorModel{}, &repository.ConversationModel{}, &repository.ConversationMemberModel{}, &repository.MessageModel{}); err != nil {
 		return fmt.Errorf("error migrating models: %v", err)
 	}
 
@@ -0,0 +1,36 @@
+package bookmark
+
+import (
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+)
+
+// MaxCollectionNameLength limits the name of a collection
+const MaxCollectionNameLength = 64
+
+var (
+	ErrPostNotFound       = errors.New("post not found")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("collection with this name already exists")
+	ErrInvalidName        = errors.New("invalid collection name")
+)
+
+// Bookmark is a post saved by a user, it is visible only to them.
+// Bookmarks without collection are kept in the default list
+type Bookmark struct {
+	UserID       string
+	PostID       string
+	CollectionID *string
+	CreatedAt    *time.Time
+	Post         *post.Post
+}
+
+// Collection is a named group of bookmarks
+type Collection struct {
+	ID        string
+	OwnerID   string
+	Name      string
+	CreatedAt *time.Time
+}
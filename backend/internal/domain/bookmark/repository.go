@@ -0,0 +1,28 @@
+package bookmark
+
+import "context"
+
+type Repository interface {
+
+	// Save bookmarks post or moves existing bookmark to collectionID
+	Save(ctx context.Context, userID, postID string, collectionID *string) error
+	Remove(ctx context.Context, userID, postID string) error
+
+	// Getters
+
+	// List retrieves bookmarks of published posts visible to userID with
+	// the posts, newest first, starting after cursor. Nil collectionID
+	// lists bookmarks of all collections
+	List(ctx context.Context, userID string, collectionID *string, cursor string, limit int) ([]*Bookmark, error)
+	// BookmarkedPostIDs reports which of postIDs are bookmarked by userID
+	BookmarkedPostIDs(ctx context.Context, userID string, postIDs []string) (map[string]bool, error)
+
+	// Collections
+	CreateCollection(ctx context.Context, c *Collection) error
+	GetCollection(ctx context.Context, ownerID, id string) (*Collection, error)
+	FindCollectionByName(ctx context.Context, ownerID, name string) (*Collection, error)
+	ListCollections(ctx context.Context, ownerID string) ([]*Collection, error)
+	RenameCollection(ctx context.Context, ownerID, id, name string) error
+	// DeleteCollection removes collection, its bookmarks stay in the default list
+	DeleteCollection(ctx context.Context, ownerID, id string) error
+}
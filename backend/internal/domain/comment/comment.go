@@ -0,0 +1,53 @@
+package comment
+
+import (
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+)
+
+// MaxDepth is the deepest level a reply can be nested at (root comments have depth 0)
+const MaxDepth = 3
+
+// MaxBodyLength limits the size of a single comment
+const MaxBodyLength = 2000
+
+// MaxThreadReplies limits replies listed with each thread, the rest are
+// loaded by the thread's RepliesCursor
+const MaxThreadReplies = 50
+
+var (
+	ErrNotFound     = errors.New("comment not found")
+	ErrForbidden    = errors.New("not authorized to modify this comment")
+	ErrMaxDepth     = errors.New("maximum reply depth reached")
+	ErrEmptyBody    = errors.New("comment body is empty")
+	ErrBodyTooLong  = errors.New("comment body is too long")
+	ErrDeleted      = errors.New("comment is deleted")
+	ErrWrongPost    = errors.New("parent comment belongs to another post")
+	ErrPostNotFound = errors.New("post not found")
+)
+
+type Comment struct {
+	ID        string
+	PostID    string
+	AuthorID  string
+	ParentID  *string
+	RootID    *string
+	Depth     int
+	Body      string
+	CreatedAt *time.Time
+	EditedAt  *time.Time
+	DeletedAt *time.Time
+	Author    user.User
+	Replies   []*Comment
+	// RepliesCursor continues replies of a root comment which were cut
+	// at MaxThreadReplies, empty when all are listed
+	RepliesCursor string
+}
+
+// IsDeleted reports whether comment was soft deleted, its body is hidden
+// but it is kept in the thread so replies stay attached
+func (c *Comment) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
@@ -0,0 +1,24 @@
+package comment
+
+import "context"
+
+type Repository interface {
+
+	// CRUD
+	Create(ctx context.Context, comment *Comment) error
+	Get(ctx context.Context, id string) (*Comment, error)
+	Update(ctx context.Context, id, body string) error
+	Delete(ctx context.Context, id string) error
+
+	// Getters
+
+	// ListRoots retrieves top-level comments of a post, oldest first,
+	// starting after cursor
+	ListRoots(ctx context.Context, postID, cursor string, limit int) ([]*Comment, error)
+	// ListReplies retrieves up to limit replies of each of the given root
+	// comments, oldest first
+	ListReplies(ctx context.Context, rootIDs []string, limit int) ([]*Comment, error)
+	// ListThread retrieves replies of a root comment, oldest first,
+	// starting after cursor
+	ListThread(ctx context.Context, rootID, cursor string, limit int) ([]*Comment, error)
+}
@@ -0,0 +1,15 @@
+package comment
+
+import "context"
+
+type Service interface {
+
+	// CRUD
+	Create(ctx context.Context, comment *Comment) error
+	Update(ctx context.Context, userID, id, body string) (*Comment, error)
+	Delete(ctx context.Context, userID, id string) error
+
+	// Getters
+	List(ctx context.Context, viewerID, postID, cursor string, limit int) ([]*Comment, string, error)
+	ListReplies(ctx context.Context, viewerID, postID, rootID, cursor string, limit int) ([]*Comment, string, error)
+}
@@ -0,0 +1,67 @@
+package group
+
+import (
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+)
+
+// MaxTitleLength limits the title of a group
+const MaxTitleLength = 100
+
+type Role string
+
+const (
+	// RoleAdmin members can rename the group and add, remove and promote members
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+)
+
+var (
+	ErrNotFound     = errors.New("group not found")
+	ErrForbidden    = errors.New("only admins can manage the group")
+	ErrInvalidTitle = errors.New("invalid group title")
+	ErrInvalidRole  = errors.New("invalid member role")
+	ErrMemberLimit  = errors.New("group member limit reached")
+	ErrNotMember    = errors.New("user is not a member of the group")
+	ErrUserNotFound = errors.New("user not found")
+	ErrLastAdmin    = errors.New("group must keep an admin")
+)
+
+// Group is a conversation of several users. Members see the history
+// from the moment they joined, earlier messages stay hidden from them
+type Group struct {
+	ID            string
+	Title         string
+	Members       []Member
+	LastMessage   *message.Message
+	LastMessageAt time.Time
+	// UnreadCount is the number of messages the viewer hasn't read
+	UnreadCount int
+	CreatedAt   *time.Time
+}
+
+type Member struct {
+	UserID     string
+	User       user.User
+	Role       Role
+	LastReadAt *time.Time
+	JoinedAt   *time.Time
+}
+
+// Member returns membership of userID, nil when they are not a member
+func (g *Group) Member(userID string) *Member {
+	for i := range g.Members {
+		if g.Members[i].UserID == userID {
+			return &g.Members[i]
+		}
+	}
+	return nil
+}
+
+func (g *Group) IsAdmin(userID string) bool {
+	m := g.Member(userID)
+	return m != nil && m.Role == RoleAdmin
+}
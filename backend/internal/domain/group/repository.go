@@ -0,0 +1,29 @@
+package group
+
+import "context"
+
+// Repository stores groups, their messages are stored by message.Repository
+type Repository interface {
+
+	// Create saves group with creator as admin and memberIDs as members
+	Create(ctx context.Context, g *Group, creatorID string, memberIDs []string) error
+	// Get retrieves group userID is a member of
+	Get(ctx context.Context, userID, id string) (*Group, error)
+	// List retrieves groups of userID with their last message, latest
+	// activity first, starting after cursor
+	List(ctx context.Context, userID, cursor string, limit int) ([]*Group, error)
+
+	// Changes below are made on behalf of actorID, who must administer the
+	// group when the change is made, ErrForbidden is returned otherwise
+
+	Rename(ctx context.Context, id, actorID, title string) error
+	// AddMembers adds users unless the group would exceed max members,
+	// ErrMemberLimit is returned then. Users who are already members are skipped
+	AddMembers(ctx context.Context, id, actorID string, userIDs []string, max int) error
+	// RemoveMember removes userID, removing oneself is leaving and needs
+	// no admin. When the last admin leaves, the earliest member is promoted
+	RemoveMember(ctx context.Context, id, actorID, userID string) error
+	// SetRole changes role of a member, ErrLastAdmin is returned when the
+	// only admin would be demoted
+	SetRole(ctx context.Context, id, actorID, userID string, role Role) error
+}
@@ -0,0 +1,82 @@
+package media
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MaxUploadSize limits a single uploaded file, nginx accepts bodies up to 10M
+	MaxUploadSize = 10 << 20
+	// MaxAltTextLength limits image description
+	MaxAltTextLength = 1500
+)
+
+var (
+	ErrNotFound           = errors.New("media not found")
+	ErrUnsupportedType    = errors.New("unsupported media type")
+	ErrTooLarge           = errors.New("media file is too large")
+	ErrAltTextTooLong     = errors.New("alt text is too long")
+	ErrStorageUnavailable = errors.New("storage is not configured")
+)
+
+// Thumbnail is a scaled down copy of an image fitting Size x Size
+type Thumbnail struct {
+	Size int    `json:"size"`
+	Path string `json:"path"`
+	URL  string `json:"url"`
+}
+
+// Media is an uploaded image, it belongs to its uploader and can be
+// attached to one of their posts or messages
+type Media struct {
+	ID          string
+	OwnerID     string
+	PostID      *string
+	MessageID   *string
+	Position    int
+	BlobHash    string
+	Path        string
+	URL         string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+	AltText     string
+	Thumbnails  []Thumbnail
+	CreatedAt   *time.Time
+
+	// Sensitive media are blurred until the viewer expands them
+	Sensitive bool
+	// Private media are stored under storage.PrivatePrefix and served by
+	// signed URLs only. Messages take only private media, media of posts
+	// with limited audience are made private when attached
+	Private bool
+}
+
+// ThumbnailURLs maps thumbnail size to its URL
+func (m *Media) ThumbnailURLs() map[int]string {
+	urls := make(map[int]string, len(m.Thumbnails))
+	for _, t := range m.Thumbnails {
+		urls[t.Size] = t.URL
+	}
+	return urls
+}
+
+// Blob is stored content of an image. Blobs are keyed by hash of the
+// content, identical uploads share one blob. Media and user photos
+// reference blobs, unreferenced blobs are collected by GC
+type Blob struct {
+	Hash        string
+	Path        string
+	URL         string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+	Thumbnails  []Thumbnail
+	CreatedAt   *time.Time
+	// LastUsedAt is updated on every new reference, GC keeps blobs used
+	// within grace period
+	LastUsedAt *time.Time
+}
@@ -0,0 +1,26 @@
+package media
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, m *Media) error
+	Get(ctx context.Context, id string) (*Media, error)
+	GetByIDs(ctx context.Context, ids []string) ([]*Media, error)
+	// MakePrivate points media to private blob b
+	MakePrivate(ctx context.Context, id string, b *Blob) error
+
+	// AcquireBlob marks existing blob with the same hash as used and fills
+	// b from it. New blobs are saved after storeContent succeeded, which
+	// runs while concurrent uploads of the same content wait
+	AcquireBlob(ctx context.Context, b *Blob, storeContent func(*Blob) error) error
+	// DeleteOrphanBlobs removes up to limit blobs which are not referenced
+	// and were last used before given time. deleteContent is called for
+	// every blob before its record is removed, while the record is locked
+	DeleteOrphanBlobs(ctx context.Context, before time.Time, limit int, deleteContent func(*Blob) error) (int, error)
+	// DeleteUnattached removes media which were uploaded before given time
+	// and never attached to a post or message
+	DeleteUnattached(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,11 @@
+package media
+
+import (
+	"context"
+	"mime/multipart"
+)
+
+type Service interface {
+	// Upload stores image of ownerID, it stays unattached until used in a post
+	Upload(ctx context.Context, ownerID string, file *multipart.FileHeader, altText string) (*Media, error)
+}
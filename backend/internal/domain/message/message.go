@@ -0,0 +1,93 @@
+package message
+
+import (
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+)
+
+const (
+	// MaxBodyLength limits text of a single message
+	MaxBodyLength = 4000
+	// MaxMedia limits how many images can be attached to a message
+	MaxMedia = 4
+)
+
+var (
+	ErrNotFound          = errors.New("conversation not found")
+	ErrEmptyMessage      = errors.New("message has no text or media")
+	ErrBodyTooLong       = errors.New("message is too long")
+	ErrTooManyMedia      = errors.New("too many media attached")
+	ErrMediaNotAvailable = errors.New("media not found or already attached")
+	ErrSelfConversation  = errors.New("cannot message yourself")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrBlocked           = errors.New("user is blocked")
+)
+
+// Conversation is a one-to-one conversation, there is at most one
+// between two users
+type Conversation struct {
+	ID            string
+	Members       []Member
+	LastMessage   *Message
+	LastMessageAt time.Time
+	// UnreadCount is the number of messages the viewer hasn't read
+	UnreadCount int
+	CreatedAt   *time.Time
+}
+
+// Member is a participant of a conversation. Messages created up to
+// LastReadAt are read by the member
+type Member struct {
+	UserID     string
+	User       user.User
+	LastReadAt *time.Time
+}
+
+// Other returns the member other than userID
+func (c *Conversation) Other(userID string) *Member {
+	for i := range c.Members {
+		if c.Members[i].UserID != userID {
+			return &c.Members[i]
+		}
+	}
+	return nil
+}
+
+// IsReadBy reports whether userID has read m
+func (c *Conversation) IsReadBy(userID string, m *Message) bool {
+	for _, member := range c.Members {
+		if member.UserID == userID {
+			return member.LastReadAt != nil && m.CreatedAt != nil && !member.LastReadAt.Before(*m.CreatedAt)
+		}
+	}
+	return false
+}
+
+// Kind of a message, system messages of groups tell who joined or left
+type Kind string
+
+const (
+	KindText    Kind = "text"
+	KindCreated Kind = "created"
+	KindJoined  Kind = "joined"
+	KindLeft    Kind = "left"
+)
+
+type Message struct {
+	ID             string
+	ConversationID string
+	Kind           Kind
+	SenderID       string
+	Sender         user.User
+	// SubjectID is the user who joined or left, set by system messages
+	SubjectID *string
+	Subject   user.User
+	Body      string
+	// MediaIDs are uploaded media attached on send
+	MediaIDs  []string
+	Media     []media.Media
+	CreatedAt *time.Time
+}
@@ -0,0 +1,29 @@
+package message
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+
+	// GetOrCreateDirect retrieves conversation of two users, it is
+	// created on first use
+	GetOrCreateDirect(ctx context.Context, userID, otherID string) (*Conversation, error)
+	// Get retrieves conversation userID is a member of
+	Get(ctx context.Context, userID, id string) (*Conversation, error)
+	// List retrieves conversations of userID with their last message,
+	// latest activity first, starting after cursor
+	List(ctx context.Context, userID, cursor string, limit int) ([]*Conversation, error)
+
+	// CreateMessage saves message and attaches its media, the sender
+	// reads the conversation up to it. m is reloaded with sender and media
+	CreateMessage(ctx context.Context, m *Message) error
+	// GetMessages retrieves messages of a conversation, newest first,
+	// starting after cursor. Messages older than since are left out when
+	// it is set, group members don't see history from before they joined
+	GetMessages(ctx context.Context, conversationID string, since *time.Time, cursor string, limit int) ([]*Message, error)
+
+	// MarkRead reads conversation of userID up to its latest message
+	MarkRead(ctx context.Context, userID, conversationID string) error
+}
@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+)
+
+// Type is the kind of event a notification is about
+type Type string
+
+const (
+	TypeFollow Type = "follow"
+	// TypeReaction is reserved for reactions to posts, nothing emits it yet
+	TypeReaction Type = "reaction"
+	TypeComment  Type = "comment"
+	TypeMention  Type = "mention"
+	// TypeRepost is sent for reposts and quotes of a post
+	TypeRepost Type = "repost"
+)
+
+// MaxActors is how many of the latest actors are listed with a notification
+const MaxActors = 3
+
+var ErrNotFound = errors.New("notification not found")
+
+// Event is a single action of ActorID which RecipientID is notified about.
+// PostID is the post the action relates to, nil for follows
+type Event struct {
+	Type        Type
+	RecipientID string
+	ActorID     string
+	PostID      *string
+	At          time.Time
+}
+
+// GroupKey identifies events which are grouped into one notification
+// while it is unread, e.g. all comments on the same post
+func (e *Event) GroupKey() string {
+	if e.PostID == nil {
+		return string(e.Type)
+	}
+	return string(e.Type) + ":" + *e.PostID
+}
+
+// Notification groups events of the same type about the same post,
+// e.g. "3 people commented on your post". Once it is read, new events
+// start a new notification
+type Notification struct {
+	ID          string
+	RecipientID string
+	Type        Type
+	PostID      *string
+	// Actors are the latest actors, newest first, at most MaxActors
+	Actors      []user.User
+	ActorsCount int
+	CreatedAt   *time.Time
+	// UpdatedAt is the time of the latest event
+	UpdatedAt time.Time
+	ReadAt    *time.Time
+}
+
+func (n *Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
@@ -0,0 +1,22 @@
+package notification
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+
+	// Add records event, it joins the unread notification of the same
+	// group or starts a new one. An actor is counted once per notification
+	Add(ctx context.Context, e *Event) error
+
+	// List retrieves notifications of recipientID, latest activity first,
+	// starting after cursor. Notifications about deleted posts are left out
+	List(ctx context.Context, recipientID, cursor string, limit int) ([]*Notification, error)
+	UnreadCount(ctx context.Context, recipientID string) (int64, error)
+
+	// MarkRead marks notification of recipientID as read at now
+	MarkRead(ctx context.Context, recipientID, id string, now time.Time) error
+	MarkAllRead(ctx context.Context, recipientID string, now time.Time) error
+}
@@ -0,0 +1,64 @@
+package post
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxHashtagLength limits a single tag, longer words are not treated as tags
+	MaxHashtagLength = 64
+	// MaxHashtags limits how many tags are stored for one post
+	MaxHashtags = 30
+)
+
+// hashtag must not be glued to a previous word, e.g. in "a#b" or urls
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_#&/])#([\p{L}\p{N}_]+)`)
+
+// NormalizeHashtag lowercases a tag and strips leading "#". Returns false
+// when tag is not a valid hashtag
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return "", false
+	}
+
+	hasLetter := false
+	for _, r := range tag {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r), r == '_':
+		default:
+			return "", false
+		}
+	}
+
+	// "#1" is a number, not a tag
+	return tag, hasLetter
+}
+
+// ParseHashtags extracts unique normalized tags from text in order of
+// appearance. The result is never nil
+func ParseHashtags(text string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	for _, m := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag, ok := NormalizeHashtag(m[1])
+		if !ok || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+
+		if len(tags) == MaxHashtags {
+			break
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,66 @@
+package post
+
+import (
+	"regexp"
+	"unicode/utf16"
+)
+
+// MaxMentions limits how many distinct users one post can mention, every
+// one of them is looked up when the post is saved
+const MaxMentions = 20
+
+// Mention links "@username" in Description to a user. Start and End are
+// offsets in UTF-16 code units, the way javascript indexes strings, and
+// cover the whole "@username" including "@"
+type Mention struct {
+	UserID   string
+	Username string
+	Start    int
+	End      int
+}
+
+// mention must not be glued to a previous word, e.g. in emails
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_]+(?:[.\-][\p{L}\p{N}_]+)*)`)
+
+// ParseMentions finds "@username" candidates in text. Returned mentions
+// have no UserID, they still have to be resolved against existing users.
+// Only the first MaxMentions distinct usernames are returned, repeated
+// mentions of them are all kept
+func ParseMentions(text string) []Mention {
+	mentions := []Mention{}
+	seen := map[string]bool{}
+
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		username := text[m[2]:m[3]]
+		if !seen[username] {
+			if len(seen) == MaxMentions {
+				continue
+			}
+			seen[username] = true
+		}
+
+		// m[2]:m[3] is the username, "@" is right before it
+		at := m[2] - 1
+		start := utf16Len(text[:at])
+
+		mentions = append(mentions, Mention{
+			Username: username,
+			Start:    start,
+			End:      start + utf16Len(text[at:m[3]]),
+		})
+	}
+
+	return mentions
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if l := utf16.RuneLen(r); l > 0 {
+			n += l
+		} else {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package post
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 4
+	// MaxPollOptionLength limits option text, in characters
+	MaxPollOptionLength = 100
+)
+
+var (
+	ErrInvalidPoll    = errors.New("poll must have 2 to 4 distinct non-empty options")
+	ErrInvalidClosing = errors.New("poll closes_at must be after the post is published")
+	ErrNoPoll         = errors.New("post has no poll")
+	ErrPollClosed     = errors.New("poll is closed")
+	ErrAlreadyVoted   = errors.New("already voted in this poll")
+	ErrInvalidChoice  = errors.New("invalid poll choice")
+)
+
+// Poll is attached to a post on creation and can't be changed later
+type Poll struct {
+	Multiple bool
+	ClosesAt time.Time
+	Options  []PollOption
+
+	// VotersCount is how many users voted, with multiple choice it can
+	// be less than the sum of option votes
+	VotersCount int
+
+	// OwnChoices are positions the viewer voted for, empty if they didn't vote
+	OwnChoices []int
+
+	// ResultsVisible is set when the viewer voted or the poll is closed,
+	// otherwise tallies are zeroed
+	ResultsVisible bool
+}
+
+// PollOption is a poll answer, Position is its index in Poll.Options
+type PollOption struct {
+	Position   int
+	Text       string
+	VotesCount int
+}
+
+// Normalize trims option texts and numbers options in order, it
+// fails unless there are 2 to 4 distinct non-empty options
+func (p *Poll) Normalize() error {
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return ErrInvalidPoll
+	}
+
+	seen := map[string]bool{}
+	for i := range p.Options {
+		text := strings.TrimSpace(p.Options[i].Text)
+		key := strings.ToLower(text)
+		if text == "" || utf8.RuneCountInString(text) > MaxPollOptionLength || seen[key] {
+			return ErrInvalidPoll
+		}
+		seen[key] = true
+
+		p.Options[i] = PollOption{Position: i, Text: text}
+	}
+
+	return nil
+}
+
+// IsClosed reports whether voting is over at now
+func (p *Poll) IsClosed(now time.Time) bool {
+	return !now.Before(p.ClosesAt)
+}
+
+// Voted reports whether the viewer voted
+func (p *Poll) Voted() bool {
+	return len(p.OwnChoices) > 0
+}
+
+// ValidateChoices checks positions picked by a voter
+func (p *Poll) ValidateChoices(choices []int) error {
+	if len(choices) == 0 || (!p.Multiple && len(choices) > 1) {
+		return ErrInvalidChoice
+	}
+
+	seen := map[int]bool{}
+	for _, c := range choices {
+		if c < 0 || c >= len(p.Options) || seen[c] {
+			return ErrInvalidChoice
+		}
+		seen[c] = true
+	}
+
+	return nil
+}
+
+// ApplyViewer sets choices of the viewer and hides tallies until they
+// vote or the poll closes
+func (p *Poll) ApplyViewer(choices []int, now time.Time) {
+	p.OwnChoices = choices
+	p.ResultsVisible = p.Voted() || p.IsClosed(now)
+	if p.ResultsVisible {
+		return
+	}
+
+	p.VotersCount = 0
+	for i := range p.Options {
+		p.Options[i].VotesCount = 0
+	}
+}
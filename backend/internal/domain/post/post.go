@@ -1,11 +1,78 @@
 package post
 
 import (
+	"errors"
 	"time"
 
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/preview"
 	"github.com/critiq17/critiqal-site/internal/domain/user"
 )
 
+const (
+	// MaxMedia limits how many images can be attached to a post
+	MaxMedia = 4
+	// MaxPinned limits how many posts a user can pin to their profile
+	MaxPinned = 3
+	// MaxContentWarningLength limits content warning text, in characters
+	MaxContentWarningLength = 500
+)
+
+type Kind string
+
+const (
+	// KindPost is a regular post written by its owner
+	KindPost Kind = "post"
+	// KindRepost boosts another post without adding any content
+	KindRepost Kind = "repost"
+	// KindQuote is a new post which references another one
+	KindQuote Kind = "quote"
+)
+
+type Status string
+
+const (
+	// StatusDraft is visible only to its owner until published
+	StatusDraft Status = "draft"
+	// StatusScheduled is published by the scheduler at PublishAt
+	StatusScheduled Status = "scheduled"
+	// StatusPublished is visible to everyone
+	StatusPublished Status = "published"
+)
+
+// Visibility is the audience of a post, it is set on creation
+type Visibility string
+
+const (
+	// VisibilityPublic posts are shown to everyone and in global listings
+	VisibilityPublic Visibility = "public"
+	// VisibilityFollowers posts are shown to followers of the owner and mentioned users
+	VisibilityFollowers Visibility = "followers"
+	// VisibilityMentioned posts are shown only to mentioned users
+	VisibilityMentioned Visibility = "mentioned"
+	// VisibilityUnlisted posts are shown to everyone who opens them but
+	// left out of global listings
+	VisibilityUnlisted Visibility = "unlisted"
+)
+
+var (
+	ErrNotFound          = errors.New("post not found")
+	ErrOriginalNotFound  = errors.New("original post not found")
+	ErrNotEditable       = errors.New("reposts cannot be edited")
+	ErrInvalidTag        = errors.New("invalid hashtag")
+	ErrTooManyMedia      = errors.New("too many media attached")
+	ErrMediaNotAvailable = errors.New("media not found or already attached")
+	ErrInvalidStatus     = errors.New("invalid post status")
+	ErrInvalidPublishAt  = errors.New("publish_at must be in the future")
+	ErrAlreadyPublished  = errors.New("post is already published")
+	ErrEditWindowClosed  = errors.New("post can no longer be edited")
+	ErrInvalidVisibility = errors.New("invalid post visibility")
+	ErrNotShareable      = errors.New("only public and unlisted posts can be reposted or quoted")
+	ErrTooManyPinned     = errors.New("too many pinned posts")
+	ErrContentWarning    = errors.New("content warning is too long")
+	ErrSensitiveMedia    = errors.New("sensitive media must be attached to the post")
+)
+
 type Post struct {
 	ID          string
 	OwnerID     string
@@ -15,4 +82,110 @@ type Post struct {
 	CreatedAt   *time.Time
 	DeletedAt   *time.Time
 	Owner       user.User
+
+	// DescriptionHTML is sanitized rendering of markdown in Description
+	DescriptionHTML string
+
+	// Kind with OriginalID describe reposts and quotes. Original is nil
+	// when the referenced post was deleted
+	Kind       Kind
+	OriginalID *string
+	Original   *Post
+
+	// Tags are parsed from Description, normalized and without "#"
+	Tags []string
+	// Mentions are "@username" in Description which belong to existing users
+	Mentions []Mention
+
+	Visibility Visibility
+
+	// Status of publishing, PublishAt is set for scheduled posts. Posts
+	// get CreatedAt of the moment they are published
+	Status    Status
+	PublishAt *time.Time
+
+	// MediaIDs are uploaded media to attach on create, Media is what is attached
+	MediaIDs []string
+	Media    []media.Media
+
+	// ContentWarning hides the post behind a warning, e.g. a spoiler.
+	// On update nil keeps the warning and empty string removes it
+	ContentWarning *string
+	// Sensitive marks all media of the post as sensitive, on update nil
+	// keeps the current value
+	Sensitive *bool
+	// SensitiveMediaIDs are attached media to flag as sensitive, on update
+	// nil keeps current flags
+	SensitiveMediaIDs []string
+	// MediaCollapsed is set when media should stay hidden in feeds of the
+	// user the post was loaded for
+	MediaCollapsed bool
+
+	CommentsCount int
+	RepostsCount  int
+
+	// EditedAt is set when published post was edited, previous versions
+	// are kept as revisions
+	EditedAt       *time.Time
+	RevisionsCount int
+
+	// PinnedAt is set while the post is pinned to its owner's profile
+	PinnedAt *time.Time
+
+	// ViewsCount is shown to the owner only, it is nil for everyone else
+	ViewsCount *int64
+
+	// Bookmarked is set for the user the post was loaded for
+	Bookmarked bool
+
+	// Poll is optional, it is created along with the post
+	Poll *Poll
+
+	// LinkPreview describes the first link in Description once it is unfurled
+	LinkPreview *preview.Preview
+}
+
+// View is a post seen by a viewer within the window starting at Bucket
+type View struct {
+	PostID   string
+	ViewerID string
+	Bucket   time.Time
+}
+
+// IsPublished reports whether post is visible to everyone
+func (p *Post) IsPublished() bool {
+	return p.Status == "" || p.Status == StatusPublished
+}
+
+// IsShareable reports whether post can be reposted or quoted, posts with
+// limited audience can't be spread further
+func (p *Post) IsShareable() bool {
+	return p.Visibility == "" || p.Visibility == VisibilityPublic || p.Visibility == VisibilityUnlisted
+}
+
+// IsSensitive reports whether all media of the post are sensitive
+func (p *Post) IsSensitive() bool {
+	return p.Sensitive != nil && *p.Sensitive
+}
+
+// HasSensitiveMedia reports whether some of attached media are sensitive
+func (p *Post) HasSensitiveMedia() bool {
+	if len(p.Media) == 0 {
+		return false
+	}
+	if p.IsSensitive() {
+		return true
+	}
+	for _, m := range p.Media {
+		if m.Sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+// OriginalUnavailable reports whether post references a deleted post,
+// quotes of purged posts lose the reference
+func (p *Post) OriginalUnavailable() bool {
+	return p.Original == nil && (p.OriginalID != nil || p.Kind == KindQuote)
 }
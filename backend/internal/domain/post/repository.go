@@ -2,18 +2,68 @@ package post
 
 import (
 	"context"
+	"time"
 )
 
 type Repository interface {
 
-	// CRUD
+	// CRUD, getters return only posts visible to viewerID
 	Create(ctx context.Context, post *Post) error
-	Get(ctx context.Context, id string) (*Post, error)
+	Get(ctx context.Context, viewerID, id string) (*Post, error)
+	// Update modifies post, previous version of published post is saved as revision
 	Update(ctx context.Context, id string, post *Post) error
 	Delete(ctx context.Context, id string) error
+	SetDescriptionHTML(ctx context.Context, id, html string) error
 
-	// Getters
-	GetPostsByUserID(ctx context.Context, user_id string) ([]*Post, error)
+	// Trash, deleted posts can be restored while they are not purged
+	GetDeleted(ctx context.Context, ownerID string, since time.Time, cursor string, limit int) ([]*Post, error)
+	Restore(ctx context.Context, ownerID, id string, since time.Time) error
+	// PurgeDeleted permanently deletes up to limit posts deleted before
+	// before and returns how many were purged
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
 
-	GetRecent(ctx context.Context, limit int) ([]*Post, error)
+	// GetRevisions retrieves previous versions of a post, newest first
+	GetRevisions(ctx context.Context, postID string) ([]*Revision, error)
+
+	// Drafts and scheduled posts, Get and listings return only published posts
+	GetDraft(ctx context.Context, ownerID, id string) (*Post, error)
+	GetDrafts(ctx context.Context, ownerID, cursor string, limit int) ([]*Post, error)
+	// PublishDue publishes up to limit scheduled posts due at now and returns their IDs
+	PublishDue(ctx context.Context, now time.Time, limit int) ([]string, error)
+	// GetPublished retrieves published posts by IDs regardless of their
+	// visibility, it is meant for background jobs
+	GetPublished(ctx context.Context, ids []string) ([]*Post, error)
+
+	// Getters, unlisted posts are left out of GetRecent and GetByTag.
+	// GetPostsByUserID returns pinned posts first
+	GetPostsByUserID(ctx context.Context, viewerID, user_id string) ([]*Post, error)
+
+	GetRecent(ctx context.Context, viewerID string, limit int) ([]*Post, error)
+	// GetTrending retrieves posts of the materialized trending ranking, highest score first
+	GetTrending(ctx context.Context, viewerID string, limit int) ([]*Post, error)
+
+	// GetByTag retrieves posts tagged with tag, newest first, starting after cursor
+	GetByTag(ctx context.Context, viewerID, tag, cursor string, limit int) ([]*Post, error)
+	// GetMentioning retrieves posts of other users which mention userID, newest first
+	GetMentioning(ctx context.Context, userID, cursor string, limit int) ([]*Post, error)
+
+	// Pin pins published post of ownerID, at most max posts stay pinned
+	Pin(ctx context.Context, ownerID, id string, max int) error
+	Unpin(ctx context.Context, ownerID, id string) error
+
+	// Vote saves choices of userID in poll of postID, ErrAlreadyVoted is
+	// returned on a second vote
+	Vote(ctx context.Context, postID, userID string, choices []int) error
+	// PollChoices retrieves positions userID voted for, keyed by post ID
+	PollChoices(ctx context.Context, userID string, postIDs []string) (map[string][]int, error)
+
+	// AddViews counts views not counted yet in their window and returns
+	// how many were new
+	AddViews(ctx context.Context, views []View) (int64, error)
+	// PruneViews forgets views of windows before bucket, they can't repeat anymore
+	PruneViews(ctx context.Context, bucket time.Time) error
+
+	// Reposts
+	FindRepost(ctx context.Context, ownerID, originalID string) (*Post, error)
+	DeleteRepost(ctx context.Context, ownerID, originalID string) error
 }
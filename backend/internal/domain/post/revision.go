@@ -0,0 +1,16 @@
+package post
+
+import "time"
+
+// Revision is a previous version of an edited post. It was shown from
+// CreatedAt until ReplacedAt
+type Revision struct {
+	ID              string
+	PostID          string
+	Title           *string
+	PhotoURL        *string
+	Description     string
+	DescriptionHTML string
+	CreatedAt       *time.Time
+	ReplacedAt      *time.Time
+}
@@ -6,10 +6,14 @@ type Service interface {
 
 	// CRUD
 	Create(ctx context.Context, post *Post) error
-	Get(ctx context.Context, id string) (*Post, error)
+	Get(ctx context.Context, viewerID, id string) (*Post, error)
 	Update(ctx context.Context, post *Post) error
 	Delete(ctx context.Context, id string) error
 
 	// Getters
-	GetPostsByUserID(ctx context.Context, user_id string) ([]*Post, error)
+	GetPostsByUserID(ctx context.Context, viewerID, user_id string) ([]*Post, error)
+
+	// Reposts
+	Repost(ctx context.Context, userID, originalID string) (*Post, error)
+	Unrepost(ctx context.Context, userID, originalID string) error
 }
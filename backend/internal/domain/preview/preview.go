@@ -0,0 +1,26 @@
+package preview
+
+import "time"
+
+// Preview is cached metadata of a linked page, keyed by the link as
+// written in posts. Failed previews are cached too, so broken links are
+// not fetched on every read
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+	FetchedAt   time.Time
+	Failed      bool
+}
+
+// IsStale reports whether preview should be fetched again at now
+func (p *Preview) IsStale(now time.Time, ttl time.Duration) bool {
+	return now.Sub(p.FetchedAt) > ttl
+}
+
+// IsEmpty reports whether there is nothing to show
+func (p *Preview) IsEmpty() bool {
+	return p.Failed || (p.Title == "" && p.Description == "" && p.ImageURL == "")
+}
@@ -0,0 +1,10 @@
+package preview
+
+import "context"
+
+type Repository interface {
+	// GetByURLs retrieves cached previews, keyed by URL
+	GetByURLs(ctx context.Context, urls []string) (map[string]*Preview, error)
+	// Save inserts or replaces preview of p.URL
+	Save(ctx context.Context, p *Preview) error
+}
@@ -0,0 +1,19 @@
+package trending
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// Engagements retrieves interactions since with public published
+	// posts, interactions of post owners are left out
+	Engagements(ctx context.Context, since time.Time) ([]Engagement, error)
+	// TagUses retrieves hashtags of public published posts created since
+	TagUses(ctx context.Context, since time.Time) ([]TagUse, error)
+
+	// Replace swaps the materialized ranking for a new one
+	Replace(ctx context.Context, posts []PostScore, tags []TagScore, computedAt time.Time) error
+	// GetTags retrieves ranked hashtags of window
+	GetTags(ctx context.Context, window time.Duration, limit int) ([]TagScore, error)
+}
@@ -0,0 +1,175 @@
+package trending
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+var ErrInvalidWindow = errors.New("unknown trending window")
+
+// EngagementKind is an interaction which makes a post trend
+type EngagementKind string
+
+const (
+	EngagementComment EngagementKind = "comment"
+	// EngagementRepost counts reposts as well as quotes
+	EngagementRepost EngagementKind = "repost"
+)
+
+// Params tune ranking, they come from configuration
+type Params struct {
+	// HalfLife is how long it takes an interaction to lose half its weight
+	HalfLife time.Duration
+	// Lookback limits how old interactions are counted
+	Lookback time.Duration
+
+	CommentWeight float64
+	RepostWeight  float64
+
+	// MaxPosts and MaxTags limit how much of the ranking is kept
+	MaxPosts int
+	MaxTags  int
+
+	// TagWindows are sliding windows hashtags are ranked over
+	TagWindows []time.Duration
+}
+
+// Engagement is a single interaction with a post by someone else than its owner
+type Engagement struct {
+	PostID string
+	Kind   EngagementKind
+	At     time.Time
+}
+
+type PostScore struct {
+	PostID string
+	Score  float64
+}
+
+// TagUse is a post which used a hashtag
+type TagUse struct {
+	Tag     string
+	PostID  string
+	OwnerID string
+	At      time.Time
+}
+
+// TagScore is popularity of a hashtag over Window, ranked by distinct
+// authors first so a single account can't push a tag
+type TagScore struct {
+	Window  time.Duration
+	Tag     string
+	Posts   int
+	Authors int
+}
+
+// ScorePosts sums weights of interactions decayed by their age at now
+// and returns posts with the highest scores first
+func ScorePosts(events []Engagement, now time.Time, p Params) []PostScore {
+	scores := map[string]float64{}
+	since := now.Add(-p.Lookback)
+
+	for _, e := range events {
+		if e.At.Before(since) || e.At.After(now) {
+			continue
+		}
+
+		weight := p.CommentWeight
+		if e.Kind == EngagementRepost {
+			weight = p.RepostWeight
+		}
+		scores[e.PostID] += weight * decay(now.Sub(e.At), p.HalfLife)
+	}
+
+	ranked := make([]PostScore, 0, len(scores))
+	for id, score := range scores {
+		if score > 0 {
+			ranked = append(ranked, PostScore{PostID: id, Score: score})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].PostID < ranked[j].PostID
+	})
+
+	if p.MaxPosts > 0 && len(ranked) > p.MaxPosts {
+		ranked = ranked[:p.MaxPosts]
+	}
+	return ranked
+}
+
+// RankTags counts uses of hashtags within every window ending at now
+func RankTags(uses []TagUse, now time.Time, p Params) []TagScore {
+	var ranked []TagScore
+
+	for _, window := range p.TagWindows {
+		since := now.Add(-window)
+		posts := map[string]int{}
+		authors := map[string]map[string]bool{}
+
+		for _, u := range uses {
+			if u.At.Before(since) || u.At.After(now) {
+				continue
+			}
+			posts[u.Tag]++
+			if authors[u.Tag] == nil {
+				authors[u.Tag] = map[string]bool{}
+			}
+			authors[u.Tag][u.OwnerID] = true
+		}
+
+		scores := make([]TagScore, 0, len(posts))
+		for tag, n := range posts {
+			scores = append(scores, TagScore{Window: window, Tag: tag, Posts: n, Authors: len(authors[tag])})
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			a, b := scores[i], scores[j]
+			if a.Authors != b.Authors {
+				return a.Authors > b.Authors
+			}
+			if a.Posts != b.Posts {
+				return a.Posts > b.Posts
+			}
+			return a.Tag < b.Tag
+		})
+
+		if p.MaxTags > 0 && len(scores) > p.MaxTags {
+			scores = scores[:p.MaxTags]
+		}
+		ranked = append(ranked, scores...)
+	}
+
+	return ranked
+}
+
+// MaxWindow is the longest period interactions or tag uses are needed for
+func (p Params) MaxWindow() time.Duration {
+	max := p.Lookback
+	for _, w := range p.TagWindows {
+		if w > max {
+			max = w
+		}
+	}
+	return max
+}
+
+// HasWindow reports whether hashtags are ranked over window
+func (p Params) HasWindow(window time.Duration) bool {
+	for _, w := range p.TagWindows {
+		if w == window {
+			return true
+		}
+	}
+	return false
+}
+
+func decay(age, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		return 1
+	}
+	return math.Exp2(-float64(age) / float64(halfLife))
+}
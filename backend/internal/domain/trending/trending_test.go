@@ -0,0 +1,140 @@
+package trending
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// now is the fake clock rankings are computed at
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+var params = Params{
+	HalfLife:      6 * time.Hour,
+	Lookback:      72 * time.Hour,
+	CommentWeight: 1,
+	RepostWeight:  2,
+	TagWindows:    []time.Duration{time.Hour, 24 * time.Hour},
+}
+
+func ago(d time.Duration) time.Time {
+	return now.Add(-d)
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScorePostsDecay(t *testing.T) {
+	scores := ScorePosts([]Engagement{
+		{PostID: "fresh", Kind: EngagementComment, At: now},
+		{PostID: "half", Kind: EngagementComment, At: ago(6 * time.Hour)},
+		{PostID: "quarter", Kind: EngagementComment, At: ago(12 * time.Hour)},
+		{PostID: "repost", Kind: EngagementRepost, At: ago(6 * time.Hour)},
+	}, now, params)
+
+	want := map[string]float64{"fresh": 1, "half": 0.5, "quarter": 0.25, "repost": 1}
+	if len(scores) != len(want) {
+		t.Fatalf("got %d scores, want %d: %+v", len(scores), len(want), scores)
+	}
+	for _, s := range scores {
+		if !approx(s.Score, want[s.PostID]) {
+			t.Errorf("score of %s = %g, want %g", s.PostID, s.Score, want[s.PostID])
+		}
+	}
+
+	// equal scores are ordered by ID
+	order := []string{"fresh", "repost", "half", "quarter"}
+	for i, s := range scores {
+		if s.PostID != order[i] {
+			t.Errorf("rank %d = %s, want %s", i, s.PostID, order[i])
+		}
+	}
+}
+
+func TestScorePostsOlderEngagementRanksLower(t *testing.T) {
+	// five day-old comments lose to one fresh repost
+	var events []Engagement
+	for i := 0; i < 5; i++ {
+		events = append(events, Engagement{PostID: "old", Kind: EngagementComment, At: ago(24 * time.Hour)})
+	}
+	events = append(events, Engagement{PostID: "new", Kind: EngagementRepost, At: ago(time.Hour)})
+
+	scores := ScorePosts(events, now, params)
+	if len(scores) != 2 || scores[0].PostID != "new" {
+		t.Errorf("ranking = %+v, want new first", scores)
+	}
+}
+
+func TestScorePostsLookback(t *testing.T) {
+	scores := ScorePosts([]Engagement{
+		{PostID: "edge", Kind: EngagementComment, At: ago(72 * time.Hour)},
+		{PostID: "stale", Kind: EngagementComment, At: ago(72*time.Hour + time.Second)},
+		{PostID: "future", Kind: EngagementComment, At: now.Add(time.Minute)},
+	}, now, params)
+
+	if len(scores) != 1 || scores[0].PostID != "edge" {
+		t.Errorf("ranking = %+v, want only edge", scores)
+	}
+}
+
+func TestScorePostsMaxPosts(t *testing.T) {
+	p := params
+	p.MaxPosts = 1
+
+	scores := ScorePosts([]Engagement{
+		{PostID: "a", Kind: EngagementComment, At: ago(time.Hour)},
+		{PostID: "b", Kind: EngagementRepost, At: ago(time.Hour)},
+	}, now, p)
+
+	if len(scores) != 1 || scores[0].PostID != "b" {
+		t.Errorf("ranking = %+v, want only b", scores)
+	}
+}
+
+func TestRankTagsWindows(t *testing.T) {
+	uses := []TagUse{
+		// go: three posts of one author within the hour
+		{Tag: "go", PostID: "1", OwnerID: "ann", At: ago(10 * time.Minute)},
+		{Tag: "go", PostID: "2", OwnerID: "ann", At: ago(20 * time.Minute)},
+		{Tag: "go", PostID: "3", OwnerID: "ann", At: ago(30 * time.Minute)},
+		// rust: two authors within the hour
+		{Tag: "rust", PostID: "4", OwnerID: "bob", At: ago(5 * time.Minute)},
+		{Tag: "rust", PostID: "5", OwnerID: "cat", At: ago(50 * time.Minute)},
+		// zig: three authors, but only within the day
+		{Tag: "zig", PostID: "6", OwnerID: "ann", At: ago(2 * time.Hour)},
+		{Tag: "zig", PostID: "7", OwnerID: "bob", At: ago(3 * time.Hour)},
+		{Tag: "zig", PostID: "8", OwnerID: "cat", At: ago(23 * time.Hour)},
+		// too old for every window
+		{Tag: "perl", PostID: "9", OwnerID: "dan", At: ago(25 * time.Hour)},
+	}
+
+	got := RankTags(uses, now, params)
+	want := []TagScore{
+		{Window: time.Hour, Tag: "rust", Posts: 2, Authors: 2},
+		{Window: time.Hour, Tag: "go", Posts: 3, Authors: 1},
+		{Window: 24 * time.Hour, Tag: "zig", Posts: 3, Authors: 3},
+		{Window: 24 * time.Hour, Tag: "rust", Posts: 2, Authors: 2},
+		{Window: 24 * time.Hour, Tag: "go", Posts: 3, Authors: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RankTags =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRankTagsMaxTags(t *testing.T) {
+	p := params
+	p.TagWindows = []time.Duration{time.Hour}
+	p.MaxTags = 1
+
+	got := RankTags([]TagUse{
+		{Tag: "b", PostID: "1", OwnerID: "ann", At: ago(time.Minute)},
+		{Tag: "a", PostID: "2", OwnerID: "bob", At: ago(time.Minute)},
+	}, now, p)
+
+	// ties are ordered by tag
+	if len(got) != 1 || got[0].Tag != "a" {
+		t.Errorf("RankTags = %+v, want only a", got)
+	}
+}
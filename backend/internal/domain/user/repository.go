@@ -10,5 +10,20 @@ type Repository interface {
 	GetUsers() ([]User, error)
 	Search(username string) ([]User, error)
 	GetUserByUsername(username string) (*User, error)
-	UpdatePhoto(username, photo_url string) error
+	UpdatePhoto(username, photo_url string, thumbnails map[int]string, blob string) error
+	UpdatePreferences(id string, prefs Preferences) error
+
+	// Followers
+	// Follow reports whether a new follow was created
+	Follow(followerID, followeeID string) (bool, error)
+	Unfollow(followerID, followeeID string) error
+	GetFolloweeIDs(followerID string) ([]string, error)
+
+	// Blocks
+	Block(blockerID, blockedID string) error
+	Unblock(blockerID, blockedID string) error
+	IsBlocked(userID, otherID string) (bool, error)
+	// AnyBlocked reports whether any of userIDs blocks any of otherIDs or
+	// is blocked by one of them
+	AnyBlocked(userIDs, otherIDs []string) (bool, error)
 }
@@ -1,6 +1,15 @@
 package user
 
-import "gorm.io/gorm"
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+	ErrCannotBlockSelf  = errors.New("cannot block yourself")
+)
 
 type User struct {
 	ID        string
@@ -12,4 +21,15 @@ type User struct {
 	PhotoURL  string
 	CreatedAt int64
 	DeletedAt gorm.DeletedAt
+
+	// PhotoThumbnails maps thumbnail size to URL of scaled avatar
+	PhotoThumbnails map[int]string
+
+	Preferences Preferences
+}
+
+// Preferences are settings of how content is shown to the user
+type Preferences struct {
+	// ExpandSensitiveMedia shows sensitive media in feeds without a click
+	ExpandSensitiveMedia bool
 }
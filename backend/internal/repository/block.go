@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// BlockModel is a block between users, blocked users can't message the
+// blocker and the blocker can't message them
+type BlockModel struct {
+	BlockerID string `gorm:"primaryKey;not null"`
+	BlockedID string `gorm:"primaryKey;index;not null"`
+	CreatedAt *time.Time
+}
+
+// Block makes blockerID block blockedID, blocking twice is a no-op
+func (r *UserRepository) Block(blockerID, blockedID string) error {
+	now := time.Now()
+	return r.db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&BlockModel{BlockerID: blockerID, BlockedID: blockedID, CreatedAt: &now}).
+		Error
+}
+
+func (r *UserRepository) Unblock(blockerID, blockedID string) error {
+	return r.db.
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Delete(&BlockModel{}).
+		Error
+}
+
+// IsBlocked reports whether either of the users blocks the other
+func (r *UserRepository) IsBlocked(userID, otherID string) (bool, error) {
+	var n int64
+	err := r.db.Model(&BlockModel{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *UserRepository) AnyBlocked(userIDs, otherIDs []string) (bool, error) {
+	if len(userIDs) == 0 || len(otherIDs) == 0 {
+		return false, nil
+	}
+
+	var n int64
+	err := r.db.Model(&BlockModel{}).
+		Where("(blocker_id IN ? AND blocked_id IN ?) OR (blocker_id IN ? AND blocked_id IN ?)", userIDs, otherIDs, otherIDs, userIDs).
+		Count(&n).Error
+	return n > 0, err
+}
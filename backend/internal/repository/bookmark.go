@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/bookmark"
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkModel struct {
+	UserID       string  `gorm:"primaryKey;not null"`
+	PostID       string  `gorm:"primaryKey;index;not null"`
+	CollectionID *string `gorm:"index"`
+	CreatedAt    *time.Time
+}
+
+type BookmarkCollectionModel struct {
+	ID        string `gorm:"primaryKey;not null"`
+	OwnerID   string `gorm:"uniqueIndex:idx_collection_name;not null"`
+	Name      string `gorm:"uniqueIndex:idx_collection_name;not null"`
+	CreatedAt *time.Time
+}
+
+type BookmarkRepository struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) *BookmarkRepository {
+	return &BookmarkRepository{db: db}
+}
+
+// BeforeCreate generates UUID and sets timestamp
+func (m *BookmarkCollectionModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.CreatedAt == nil {
+		now := time.Now()
+		m.CreatedAt = &now
+	}
+	return nil
+}
+
+func (m *BookmarkCollectionModel) toDomain() *bookmark.Collection {
+	return &bookmark.Collection{
+		ID:        m.ID,
+		OwnerID:   m.OwnerID,
+		Name:      m.Name,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// Save inserts bookmark, saving it again only moves it to collectionID
+func (r *BookmarkRepository) Save(ctx context.Context, userID, postID string, collectionID *string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+		}).
+		Create(&BookmarkModel{
+			UserID:       userID,
+			PostID:       postID,
+			CollectionID: collectionID,
+			CreatedAt:    &now,
+		}).Error
+}
+
+func (r *BookmarkRepository) Remove(ctx context.Context, userID, postID string) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND post_id = ?", userID, postID).
+		Delete(&BookmarkModel{}).
+		Error
+}
+
+// List selects a page of bookmarks first and loads their posts after,
+// bookmarks of posts which are gone or no longer visible are skipped
+func (r *BookmarkRepository) List(ctx context.Context, userID string, collectionID *string, after string, limit int) ([]*bookmark.Bookmark, error) {
+	var models []BookmarkModel
+
+	q := r.db.WithContext(ctx).
+		Model(&BookmarkModel{}).
+		Select("bookmark_models.*").
+		Joins("JOIN post_models ON post_models.id = bookmark_models.post_id").
+		Scopes(published, visibleTo(userID)).
+		Where("bookmark_models.user_id = ? AND post_models.deleted_at IS NULL", userID)
+
+	if collectionID != nil {
+		q = q.Where("bookmark_models.collection_id = ?", *collectionID)
+	}
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(bookmark_models.created_at, bookmark_models.post_id) < (?, ?)", t, id)
+	}
+
+	err := q.Order("bookmark_models.created_at DESC, bookmark_models.post_id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil || len(models) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.PostID
+	}
+
+	var posts []*PostModel
+	err = r.db.WithContext(ctx).
+		Scopes(withPostCounts, withOriginal, withDetails).
+		Where("post_models.id IN ?", ids).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*post.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = toDomainPost(p)
+	}
+
+	bookmarks := make([]*bookmark.Bookmark, 0, len(models))
+	for _, m := range models {
+		p, ok := byID[m.PostID]
+		if !ok {
+			continue
+		}
+		bookmarks = append(bookmarks, &bookmark.Bookmark{
+			UserID:       m.UserID,
+			PostID:       m.PostID,
+			CollectionID: m.CollectionID,
+			CreatedAt:    m.CreatedAt,
+			Post:         p,
+		})
+	}
+
+	return bookmarks, nil
+}
+
+func (r *BookmarkRepository) BookmarkedPostIDs(ctx context.Context, userID string, postIDs []string) (map[string]bool, error) {
+	bookmarked := map[string]bool{}
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&BookmarkModel{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
+
+func (r *BookmarkRepository) CreateCollection(ctx context.Context, c *bookmark.Collection) error {
+	model := &BookmarkCollectionModel{
+		OwnerID: c.OwnerID,
+		Name:    c.Name,
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+
+	*c = *model.toDomain()
+	return nil
+}
+
+func (r *BookmarkRepository) GetCollection(ctx context.Context, ownerID, id string) (*bookmark.Collection, error) {
+	return r.findCollection(r.db.WithContext(ctx).Where("owner_id = ? AND id = ?", ownerID, id))
+}
+
+func (r *BookmarkRepository) FindCollectionByName(ctx context.Context, ownerID, name string) (*bookmark.Collection, error) {
+	return r.findCollection(r.db.WithContext(ctx).Where("owner_id = ? AND name = ?", ownerID, name))
+}
+
+func (r *BookmarkRepository) findCollection(q *gorm.DB) (*bookmark.Collection, error) {
+	var model BookmarkCollectionModel
+
+	err := q.First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, bookmark.ErrCollectionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return model.toDomain(), nil
+}
+
+func (r *BookmarkRepository) ListCollections(ctx context.Context, ownerID string) ([]*bookmark.Collection, error) {
+	var models []BookmarkCollectionModel
+
+	err := r.db.WithContext(ctx).
+		Where("owner_id = ?", ownerID).
+		Order("name ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]*bookmark.Collection, len(models))
+	for i := range models {
+		collections[i] = models[i].toDomain()
+	}
+	return collections, nil
+}
+
+func (r *BookmarkRepository) RenameCollection(ctx context.Context, ownerID, id, name string) error {
+	return r.db.WithContext(ctx).
+		Model(&BookmarkCollectionModel{}).
+		Where("owner_id = ? AND id = ?", ownerID, id).
+		Update("name", name).
+		Error
+}
+
+func (r *BookmarkRepository) DeleteCollection(ctx context.Context, ownerID, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&BookmarkModel{}).
+			Where("user_id = ? AND collection_id = ?", ownerID, id).
+			Update("collection_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("owner_id = ? AND id = ?", ownerID, id).
+			Delete(&BookmarkCollectionModel{}).
+			Error
+	})
+}
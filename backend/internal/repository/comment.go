@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/comment"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CommentModel struct {
+	ID        string  `gorm:"primaryKey;not null"`
+	PostID    string  `gorm:"index;not null"`
+	AuthorID  string  `gorm:"index;not null"`
+	ParentID  *string `gorm:"index"`
+	RootID    *string `gorm:"index"`
+	Depth     int     `gorm:"not null;default:0"`
+	Body      string  `gorm:"not null"`
+	CreatedAt *time.Time
+	EditedAt  *time.Time
+	DeletedAt *time.Time `gorm:"index"`
+
+	Author User `gorm:"foreignKey:AuthorID;references:ID"`
+}
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// toDomainComment converts database model to domain model
+func toDomainComment(m *CommentModel) *comment.Comment {
+	c := &comment.Comment{
+		ID:        m.ID,
+		PostID:    m.PostID,
+		AuthorID:  m.AuthorID,
+		ParentID:  m.ParentID,
+		RootID:    m.RootID,
+		Depth:     m.Depth,
+		Body:      m.Body,
+		CreatedAt: m.CreatedAt,
+		EditedAt:  m.EditedAt,
+		DeletedAt: m.DeletedAt,
+	}
+
+	if m.Author.ID != "" {
+		c.Author = *m.Author.toDomain()
+		c.Author.Password = ""
+	}
+
+	return c
+}
+
+func toDomainComments(models []*CommentModel) []*comment.Comment {
+	comments := make([]*comment.Comment, len(models))
+	for i, m := range models {
+		comments[i] = toDomainComment(m)
+	}
+	return comments
+}
+
+// BeforeCreate generates UUID and sets timestamp
+func (m *CommentModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.CreatedAt == nil {
+		now := time.Now()
+		m.CreatedAt = &now
+	}
+	return nil
+}
+
+func (r *CommentRepository) Create(ctx context.Context, c *comment.Comment) error {
+	model := &CommentModel{
+		ID:       c.ID,
+		PostID:   c.PostID,
+		AuthorID: c.AuthorID,
+		ParentID: c.ParentID,
+		RootID:   c.RootID,
+		Depth:    c.Depth,
+		Body:     c.Body,
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+
+	c.ID = model.ID
+	c.CreatedAt = model.CreatedAt
+
+	return nil
+}
+
+// Get retrieves a single comment by ID, soft deleted comments included
+func (r *CommentRepository) Get(ctx context.Context, id string) (*comment.Comment, error) {
+	var model CommentModel
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("id = ?", id).
+		First(&model).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, comment.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainComment(&model), nil
+}
+
+// Update replaces comment body and marks it as edited
+func (r *CommentRepository) Update(ctx context.Context, id, body string) error {
+	return r.db.WithContext(ctx).
+		Model(&CommentModel{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"body":      body,
+			"edited_at": time.Now(),
+		}).
+		Error
+}
+
+// Delete soft deletes a comment, the row is kept to preserve thread structure
+func (r *CommentRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).
+		Model(&CommentModel{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Update("deleted_at", time.Now()).
+		Error
+}
+
+// ListRoots retrieves top-level comments of a post. Deleted roots are
+// returned only while they still have live replies
+func (r *CommentRepository) ListRoots(ctx context.Context, postID, after string, limit int) ([]*comment.Comment, error) {
+	var models []*CommentModel
+
+	q := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("post_id = ? AND parent_id IS NULL", postID).
+		Where("deleted_at IS NULL OR EXISTS (?)",
+			r.db.Table("comment_models AS replies").
+				Select("1").
+				Where("replies.root_id = comment_models.id AND replies.deleted_at IS NULL"),
+		)
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(created_at, id) > (?, ?)", t, id)
+	}
+
+	err := q.Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&models).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainComments(models), nil
+}
+
+// ListReplies retrieves the first replies of every given thread, oldest
+// first. Replies are numbered per thread, so a busy thread doesn't
+// crowd out the others
+func (r *CommentRepository) ListReplies(ctx context.Context, rootIDs []string, limit int) ([]*comment.Comment, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	numbered := r.db.Session(&gorm.Session{NewDB: true}).
+		Model(&CommentModel{}).
+		Select("comment_models.*, ROW_NUMBER() OVER (PARTITION BY root_id ORDER BY created_at ASC, id ASC) AS reply_number").
+		Where("root_id IN ?", rootIDs)
+
+	var models []*CommentModel
+
+	err := r.db.WithContext(ctx).
+		Preload("Author").
+		Table("(?) AS comment_models", numbered).
+		Where("reply_number <= ?", limit).
+		Order("created_at ASC, id ASC").
+		Find(&models).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainComments(models), nil
+}
+
+func (r *CommentRepository) ListThread(ctx context.Context, rootID, after string, limit int) ([]*comment.Comment, error) {
+	q := r.db.WithContext(ctx).
+		Preload("Author").
+		Where("root_id = ?", rootID)
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(created_at, id) > (?, ?)", t, id)
+	}
+
+	var models []*CommentModel
+
+	err := q.Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&models).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainComments(models), nil
+}
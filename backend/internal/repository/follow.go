@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// FollowModel is a follower relationship, followers see followers-only posts
+type FollowModel struct {
+	FollowerID string `gorm:"primaryKey;not null"`
+	FolloweeID string `gorm:"primaryKey;index;not null"`
+	CreatedAt  *time.Time
+}
+
+// Follow makes followerID follow followeeID, following twice is a no-op
+// and reports false
+func (r *UserRepository) Follow(followerID, followeeID string) (bool, error) {
+	now := time.Now()
+	res := r.db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&FollowModel{FollowerID: followerID, FolloweeID: followeeID, CreatedAt: &now})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *UserRepository) Unfollow(followerID, followeeID string) error {
+	return r.db.
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&FollowModel{}).
+		Error
+}
+
+// GetFolloweeIDs retrieves IDs of users followerID follows
+func (r *UserRepository) GetFolloweeIDs(followerID string) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&FollowModel{}).
+		Where("follower_id = ?", followerID).
+		Pluck("followee_id", &ids).Error
+	return ids, err
+}
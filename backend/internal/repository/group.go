@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/group"
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GroupRepository stores groups as conversations of kind group, their
+// messages are handled by MessageRepository
+type GroupRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupRepository(db *gorm.DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+func toDomainGroup(m *ConversationModel) *group.Group {
+	g := &group.Group{
+		ID:          m.ID,
+		Title:       stringValue(m.Title),
+		UnreadCount: m.UnreadCount,
+		CreatedAt:   m.CreatedAt,
+		Members:     make([]group.Member, len(m.Members)),
+	}
+	if m.LastMessageAt != nil {
+		g.LastMessageAt = *m.LastMessageAt
+	}
+	for i, member := range m.Members {
+		g.Members[i] = group.Member{
+			UserID:     member.UserID,
+			Role:       group.Role(member.Role),
+			LastReadAt: member.LastReadAt,
+			JoinedAt:   member.JoinedAt,
+		}
+		if member.User.ID != "" {
+			g.Members[i].User = *member.User.toDomain()
+			g.Members[i].User.Password = ""
+		}
+	}
+	return g
+}
+
+// groups leaves out direct conversations
+func groups(db *gorm.DB) *gorm.DB {
+	return db.Where("conversation_models.kind = ?", conversationGroup)
+}
+
+// systemMessages builds messages of actorID about subjects, a microsecond
+// apart so they keep their order in pagination
+func systemMessages(conversationID, actorID string, kind message.Kind, subjects []string, at time.Time) []MessageModel {
+	messages := make([]MessageModel, len(subjects))
+	for i := range subjects {
+		messages[i] = MessageModel{
+			ConversationID: conversationID,
+			Kind:           string(kind),
+			SenderID:       actorID,
+			SubjectID:      &subjects[i],
+			CreatedAt:      at.Add(time.Duration(i) * time.Microsecond),
+		}
+	}
+	return messages
+}
+
+// addSystemMessages saves messages and moves activity of the group to the last one
+func addSystemMessages(tx *gorm.DB, conversationID string, messages []MessageModel) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := tx.Create(&messages).Error; err != nil {
+		return err
+	}
+
+	last := messages[len(messages)-1].CreatedAt
+	return tx.Model(&ConversationModel{}).
+		Where("id = ?", conversationID).
+		Update("last_message_at", gorm.Expr("GREATEST(last_message_at, ?)", last)).Error
+}
+
+func (r *GroupRepository) Create(ctx context.Context, g *group.Group, creatorID string, memberIDs []string) error {
+	now := time.Now()
+	model := &ConversationModel{
+		Kind:      conversationGroup,
+		Title:     &g.Title,
+		CreatedAt: &now,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+
+		members := []ConversationMemberModel{{
+			ConversationID: model.ID,
+			UserID:         creatorID,
+			Role:           string(group.RoleAdmin),
+			JoinedAt:       &now,
+		}}
+		for _, id := range memberIDs {
+			members = append(members, ConversationMemberModel{
+				ConversationID: model.ID,
+				UserID:         id,
+				Role:           string(group.RoleMember),
+				JoinedAt:       &now,
+			})
+		}
+		if err := tx.Create(&members).Error; err != nil {
+			return err
+		}
+
+		messages := systemMessages(model.ID, creatorID, message.KindJoined, memberIDs, now.Add(time.Microsecond))
+		messages = append([]MessageModel{{
+			ConversationID: model.ID,
+			Kind:           string(message.KindCreated),
+			SenderID:       creatorID,
+			CreatedAt:      now,
+		}}, messages...)
+		if err := addSystemMessages(tx, model.ID, messages); err != nil {
+			return err
+		}
+
+		// history before joining is not unread
+		last := messages[len(messages)-1].CreatedAt
+		return tx.Model(&ConversationMemberModel{}).
+			Where("conversation_id = ?", model.ID).
+			Update("last_read_at", last).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	g.ID = model.ID
+	g.CreatedAt = model.CreatedAt
+	return nil
+}
+
+func (r *GroupRepository) Get(ctx context.Context, userID, id string) (*group.Group, error) {
+	var model ConversationModel
+	err := r.db.WithContext(ctx).
+		Scopes(withConversation(userID), groups).
+		Where("conversation_models.id = ?", id).
+		First(&model).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, group.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := r.withLastMessages(ctx, &model)
+	if err != nil {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+func (r *GroupRepository) List(ctx context.Context, userID, after string, limit int) ([]*group.Group, error) {
+	q := r.db.WithContext(ctx).
+		Scopes(withConversation(userID), groups)
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(conversation_models.last_message_at, conversation_models.id) < (?, ?)", t, id)
+	}
+
+	var models []*ConversationModel
+	err := q.Order("conversation_models.last_message_at DESC, conversation_models.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withLastMessages(ctx, models...)
+}
+
+func (r *GroupRepository) withLastMessages(ctx context.Context, models ...*ConversationModel) ([]*group.Group, error) {
+	last, err := lastMessages(r.db.WithContext(ctx), models)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*group.Group, len(models))
+	for i, m := range models {
+		list[i] = toDomainGroup(m)
+		list[i].LastMessage = last[m.ID]
+	}
+	return list, nil
+}
+
+func (r *GroupRepository) Rename(ctx context.Context, id, actorID, title string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockGroup(tx, id); err != nil {
+			return err
+		}
+		if err := checkAdmin(tx, id, actorID); err != nil {
+			return err
+		}
+
+		return tx.Model(&ConversationModel{}).
+			Where("id = ?", id).
+			Update("title", title).Error
+	})
+}
+
+// lockGroup locks group row, membership changes of a group are serialized
+func lockGroup(tx *gorm.DB, id string) error {
+	var model ConversationModel
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND kind = ?", id, conversationGroup).
+		First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return group.ErrNotFound
+	}
+	return err
+}
+
+// checkAdmin fails with group.ErrForbidden unless actorID administers
+// locked group. Roles are checked again under the lock, the actor may have
+// been demoted or removed since the service looked
+func checkAdmin(tx *gorm.DB, id, actorID string) error {
+	var n int64
+	err := tx.Model(&ConversationMemberModel{}).
+		Where("conversation_id = ? AND user_id = ? AND role = ?", id, actorID, group.RoleAdmin).
+		Count(&n).Error
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return group.ErrForbidden
+	}
+	return nil
+}
+
+func (r *GroupRepository) AddMembers(ctx context.Context, id, actorID string, userIDs []string, max int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockGroup(tx, id); err != nil {
+			return err
+		}
+		if err := checkAdmin(tx, id, actorID); err != nil {
+			return err
+		}
+
+		var existing []string
+		err := tx.Model(&ConversationMemberModel{}).
+			Where("conversation_id = ?", id).
+			Pluck("user_id", &existing).Error
+		if err != nil {
+			return err
+		}
+
+		members := make(map[string]bool, len(existing))
+		for _, userID := range existing {
+			members[userID] = true
+		}
+		var added []string
+		for _, userID := range userIDs {
+			if !members[userID] {
+				members[userID] = true
+				added = append(added, userID)
+			}
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		if len(members) > max {
+			return group.ErrMemberLimit
+		}
+
+		now := time.Now()
+		models := make([]ConversationMemberModel, len(added))
+		for i, userID := range added {
+			models[i] = ConversationMemberModel{
+				ConversationID: id,
+				UserID:         userID,
+				Role:           string(group.RoleMember),
+				JoinedAt:       &now,
+				LastReadAt:     &now,
+			}
+		}
+		if err := tx.Create(&models).Error; err != nil {
+			return err
+		}
+
+		return addSystemMessages(tx, id, systemMessages(id, actorID, message.KindJoined, added, now))
+	})
+}
+
+func (r *GroupRepository) RemoveMember(ctx context.Context, id, actorID, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockGroup(tx, id); err != nil {
+			return err
+		}
+		// members leave on their own, removing others takes an admin
+		if actorID != userID {
+			if err := checkAdmin(tx, id, actorID); err != nil {
+				return err
+			}
+		}
+
+		res := tx.Where("conversation_id = ? AND user_id = ?", id, userID).
+			Delete(&ConversationMemberModel{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return group.ErrNotMember
+		}
+
+		err := addSystemMessages(tx, id, systemMessages(id, actorID, message.KindLeft, []string{userID}, time.Now()))
+		if err != nil {
+			return err
+		}
+
+		var admins int64
+		err = tx.Model(&ConversationMemberModel{}).
+			Where("conversation_id = ? AND role = ?", id, group.RoleAdmin).
+			Count(&admins).Error
+		if err != nil || admins > 0 {
+			return err
+		}
+
+		// the longest standing member takes over, an empty group stays without admin
+		var first ConversationMemberModel
+		err = tx.Where("conversation_id = ?", id).
+			Order("joined_at ASC, user_id ASC").
+			First(&first).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&ConversationMemberModel{}).
+			Where("conversation_id = ? AND user_id = ?", id, first.UserID).
+			Update("role", group.RoleAdmin).Error
+	})
+}
+
+func (r *GroupRepository) SetRole(ctx context.Context, id, actorID, userID string, role group.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockGroup(tx, id); err != nil {
+			return err
+		}
+		if err := checkAdmin(tx, id, actorID); err != nil {
+			return err
+		}
+
+		if role != group.RoleAdmin {
+			var others int64
+			err := tx.Model(&ConversationMemberModel{}).
+				Where("conversation_id = ? AND role = ? AND user_id <> ?", id, group.RoleAdmin, userID).
+				Count(&others).Error
+			if err != nil {
+				return err
+			}
+			if others == 0 {
+				return group.ErrLastAdmin
+			}
+		}
+
+		res := tx.Model(&ConversationMemberModel{}).
+			Where("conversation_id = ? AND user_id = ?", id, userID).
+			Update("role", role)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return group.ErrNotMember
+		}
+		return nil
+	})
+}
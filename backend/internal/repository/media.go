@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MediaModel struct {
+	ID          string  `gorm:"primaryKey;not null"`
+	OwnerID     string  `gorm:"index;not null"`
+	PostID      *string `gorm:"index"`
+	MessageID   *string `gorm:"index"`
+	Position    int     `gorm:"not null;default:0"`
+	BlobHash    *string `gorm:"index"`
+	Path        string  `gorm:"not null"`
+	URL         string  `gorm:"not null"`
+	ContentType string  `gorm:"not null"`
+	Size        int64   `gorm:"not null"`
+	Width       int     `gorm:"not null"`
+	Height      int     `gorm:"not null"`
+	AltText     string  `gorm:"not null;default:''"`
+	Sensitive   bool    `gorm:"not null;default:false"`
+	Private     bool    `gorm:"not null;default:false"`
+	CreatedAt   *time.Time
+
+	Thumbnails []media.Thumbnail `gorm:"type:jsonb;serializer:json"`
+}
+
+// BlobModel is stored content shared by identical uploads
+type BlobModel struct {
+	Hash        string `gorm:"primaryKey;not null"`
+	Path        string `gorm:"not null"`
+	URL         string `gorm:"not null"`
+	ContentType string `gorm:"not null"`
+	Size        int64  `gorm:"not null"`
+	Width       int    `gorm:"not null"`
+	Height      int    `gorm:"not null"`
+	CreatedAt   *time.Time
+	LastUsedAt  *time.Time `gorm:"index;not null"`
+
+	Thumbnails []media.Thumbnail `gorm:"type:jsonb;serializer:json"`
+}
+
+type MediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) *MediaRepository {
+	return &MediaRepository{db: db}
+}
+
+func (m *MediaModel) toDomain() *media.Media {
+	return &media.Media{
+		ID:          m.ID,
+		OwnerID:     m.OwnerID,
+		PostID:      m.PostID,
+		MessageID:   m.MessageID,
+		Position:    m.Position,
+		BlobHash:    stringValue(m.BlobHash),
+		Path:        m.Path,
+		URL:         m.URL,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		AltText:     m.AltText,
+		Thumbnails:  m.Thumbnails,
+		CreatedAt:   m.CreatedAt,
+		Sensitive:   m.Sensitive,
+		Private:     m.Private,
+	}
+}
+
+func (m *BlobModel) toDomain() *media.Blob {
+	return &media.Blob{
+		Hash:        m.Hash,
+		Path:        m.Path,
+		URL:         m.URL,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		Thumbnails:  m.Thumbnails,
+		CreatedAt:   m.CreatedAt,
+		LastUsedAt:  m.LastUsedAt,
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func toDomainMediaList(models []MediaModel) []media.Media {
+	list := make([]media.Media, len(models))
+	for i, m := range models {
+		list[i] = *m.toDomain()
+	}
+	return list
+}
+
+// BeforeCreate generates UUID and sets timestamp
+func (m *MediaModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.CreatedAt == nil {
+		now := time.Now()
+		m.CreatedAt = &now
+	}
+	return nil
+}
+
+// withMedia preloads attached media of posts in their order
+func withMedia(db *gorm.DB) *gorm.DB {
+	return db.Preload("Media", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
+
+// attachMedia links uploaded media to a post. Media must belong to ownerID
+// and must not be attached to another post yet
+func attachMedia(tx *gorm.DB, postID, ownerID string, ids []string) error {
+	for i, id := range ids {
+		res := tx.Model(&MediaModel{}).
+			Where("id = ? AND owner_id = ? AND post_id IS NULL AND message_id IS NULL", id, ownerID).
+			Updates(map[string]interface{}{
+				"post_id":  postID,
+				"position": i,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return post.ErrMediaNotAvailable
+		}
+	}
+	return nil
+}
+
+// markSensitiveMedia flags media of a post listed in ids as sensitive
+// and clears the flag on the rest
+func markSensitiveMedia(tx *gorm.DB, postID string, ids []string) error {
+	q := tx.Model(&MediaModel{}).Where("post_id = ?", postID)
+	if len(ids) == 0 {
+		return q.Update("sensitive", false).Error
+	}
+	return q.Update("sensitive", gorm.Expr("id IN ?", ids)).Error
+}
+
+func (r *MediaRepository) Create(ctx context.Context, m *media.Media) error {
+	model := &MediaModel{
+		ID:          m.ID,
+		OwnerID:     m.OwnerID,
+		BlobHash:    nullableString(m.BlobHash),
+		Path:        m.Path,
+		URL:         m.URL,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		AltText:     m.AltText,
+		Private:     m.Private,
+		Thumbnails:  m.Thumbnails,
+	}
+
+	if err := r.db.WithContext(ctx).Create(model).Error; err != nil {
+		return err
+	}
+
+	m.ID = model.ID
+	m.CreatedAt = model.CreatedAt
+
+	return nil
+}
+
+func (r *MediaRepository) Get(ctx context.Context, id string) (*media.Media, error) {
+	var model MediaModel
+
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, media.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return model.toDomain(), nil
+}
+
+func (r *MediaRepository) GetByIDs(ctx context.Context, ids []string) ([]*media.Media, error) {
+	var models []MediaModel
+
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	list := make([]*media.Media, len(models))
+	for i := range models {
+		list[i] = models[i].toDomain()
+	}
+	return list, nil
+}
+
+// blobReferenced matches blobs used by media or as user photo. Media of
+// soft deleted posts still reference their blobs
+const blobReferenced = `EXISTS (SELECT 1 FROM media_models WHERE media_models.blob_hash = blob_models.hash)
+	OR EXISTS (SELECT 1 FROM users WHERE users.photo_blob = blob_models.hash)`
+
+// AcquireBlob inserts blob or bumps last_used_at of existing one. The
+// upsert waits for GC holding the row, so a blob is never reused while
+// its content is being deleted
+func (r *MediaRepository) MakePrivate(ctx context.Context, id string, b *media.Blob) error {
+	return r.db.WithContext(ctx).
+		Model(&MediaModel{}).
+		Where("id = ?", id).
+		Select("blob_hash", "path", "url", "thumbnails", "private").
+		Updates(&MediaModel{
+			BlobHash:   &b.Hash,
+			Path:       b.Path,
+			URL:        b.URL,
+			Thumbnails: b.Thumbnails,
+			Private:    true,
+		}).Error
+}
+
+func (r *MediaRepository) AcquireBlob(ctx context.Context, b *media.Blob, storeContent func(*media.Blob) error) error {
+	now := time.Now()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// xmax is zero only for rows inserted by this statement
+		var created bool
+		err := tx.Raw(`INSERT INTO blob_models (hash, path, url, content_type, size, width, height, thumbnails, created_at, last_used_at)
+			VALUES (?, ?, '', ?, ?, ?, ?, '[]'::jsonb, ?, ?)
+			ON CONFLICT (hash) DO UPDATE SET last_used_at = EXCLUDED.last_used_at
+			RETURNING xmax = 0`,
+			b.Hash, b.Path, b.ContentType, b.Size, b.Width, b.Height, now, now,
+		).Row().Scan(&created)
+		if err != nil {
+			return err
+		}
+
+		var model BlobModel
+		if !created {
+			if err := tx.Where("hash = ?", b.Hash).First(&model).Error; err != nil {
+				return err
+			}
+			*b = *model.toDomain()
+			return nil
+		}
+
+		// uploads of the same content wait on the uncommitted row, so
+		// they see the blob only after its content is stored
+		if err := storeContent(b); err != nil {
+			return err
+		}
+
+		return tx.Model(&BlobModel{}).
+			Where("hash = ?", b.Hash).
+			Select("url", "thumbnails").
+			Updates(&BlobModel{URL: b.URL, Thumbnails: b.Thumbnails}).
+			Error
+	})
+}
+
+// DeleteOrphanBlobs locks candidates with SKIP LOCKED, so several
+// replicas can collect at once without touching the same blob
+func (r *MediaRepository) DeleteOrphanBlobs(ctx context.Context, before time.Time, limit int, deleteContent func(*media.Blob) error) (int, error) {
+	deleted := 0
+	var contentErr error
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var models []BlobModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("last_used_at < ?", before).
+			Where("NOT (" + blobReferenced + ")").
+			Order("last_used_at ASC").
+			Limit(limit).
+			Find(&models).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range models {
+			// records of blobs deleted so far are committed anyway, their
+			// content is already gone
+			if err := deleteContent(models[i].toDomain()); err != nil {
+				contentErr = fmt.Errorf("delete blob %s: %w", models[i].Hash, err)
+				return nil
+			}
+			if err := tx.Where("hash = ?", models[i].Hash).Delete(&BlobModel{}).Error; err != nil {
+				return err
+			}
+			deleted++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, contentErr
+}
+
+func (r *MediaRepository) DeleteUnattached(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("post_id IS NULL AND message_id IS NULL AND created_at < ?", before).
+		Delete(&MediaModel{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"gorm.io/gorm"
+)
+
+// MentionModel is a resolved "@username" inside post description
+type MentionModel struct {
+	PostID      string `gorm:"primaryKey;not null"`
+	StartOffset int    `gorm:"primaryKey;not null"`
+	EndOffset   int    `gorm:"not null"`
+	UserID      string `gorm:"index;not null"`
+	Username    string `gorm:"not null"`
+}
+
+// withMentions preloads mentions of posts in order of appearance in the text
+func withMentions(db *gorm.DB) *gorm.DB {
+	return db.Preload("Mentions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_offset ASC")
+	})
+}
+
+func toDomainMentions(models []MentionModel) []post.Mention {
+	mentions := make([]post.Mention, len(models))
+	for i, m := range models {
+		mentions[i] = post.Mention{
+			UserID:   m.UserID,
+			Username: m.Username,
+			Start:    m.StartOffset,
+			End:      m.EndOffset,
+		}
+	}
+	return mentions
+}
+
+// syncPostMentions replaces mentions of a post with the given ones
+func syncPostMentions(tx *gorm.DB, postID string, mentions []post.Mention) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&MentionModel{}).Error; err != nil {
+		return err
+	}
+
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	models := make([]MentionModel, len(mentions))
+	for i, m := range mentions {
+		models[i] = MentionModel{
+			PostID:      postID,
+			StartOffset: m.Start,
+			EndOffset:   m.End,
+			UserID:      m.UserID,
+			Username:    m.Username,
+		}
+	}
+
+	return tx.Create(&models).Error
+}
+
+// GetMentioning retrieves posts of other users which mention userID, newest
+// first. Posts of users blocked by userID or blocking them are left out
+func (r *PostRepository) GetMentioning(ctx context.Context, userID, after string, limit int) ([]*post.Post, error) {
+	blocks := r.db.Session(&gorm.Session{NewDB: true}).
+		Model(&BlockModel{}).
+		Select("1").
+		Where("(block_models.blocker_id = ? AND block_models.blocked_id = post_models.owner_id) OR (block_models.blocker_id = post_models.owner_id AND block_models.blocked_id = ?)", userID, userID)
+
+	q := r.db.WithContext(ctx).
+		Scopes(published, visibleTo(userID)).
+		Where("post_models.deleted_at IS NULL AND post_models.owner_id <> ?", userID).
+		Where("post_models.id IN (?)",
+			r.db.Model(&MentionModel{}).Select("post_id").Where("user_id = ?", userID),
+		).
+		Where("NOT EXISTS (?)", blocks)
+
+	return r.findPage(q, after, limit)
+}
@@ -0,0 +1,420 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of conversations, groups are stored beside direct conversations
+const (
+	conversationDirect = "direct"
+	conversationGroup  = "group"
+)
+
+type ConversationModel struct {
+	ID   string `gorm:"primaryKey;not null"`
+	Kind string `gorm:"not null;default:direct"`
+	// DirectKey is "<user ID>:<user ID>" of the members sorted, it keeps
+	// one conversation per pair
+	DirectKey *string `gorm:"uniqueIndex"`
+	Title     *string
+	CreatedAt *time.Time
+	// LastMessageAt is null until the first message, empty conversations aren't listed
+	LastMessageAt *time.Time `gorm:"index"`
+
+	UnreadCount int `gorm:"->;-:migration"`
+
+	Members []ConversationMemberModel `gorm:"foreignKey:ConversationID;references:ID"`
+}
+
+type ConversationMemberModel struct {
+	ConversationID string `gorm:"primaryKey;not null"`
+	UserID         string `gorm:"primaryKey;index;not null"`
+	Role           string `gorm:"not null;default:member"`
+	LastReadAt     *time.Time
+	JoinedAt       *time.Time
+
+	User User `gorm:"foreignKey:UserID;references:ID"`
+}
+
+type MessageModel struct {
+	ID             string    `gorm:"primaryKey;not null"`
+	ConversationID string    `gorm:"index:idx_message_conversation,priority:1;not null"`
+	Kind           string    `gorm:"not null;default:text"`
+	SenderID       string    `gorm:"index;not null"`
+	SubjectID      *string   `gorm:"index"`
+	Body           string    `gorm:"not null;default:''"`
+	CreatedAt      time.Time `gorm:"index:idx_message_conversation,priority:2;not null"`
+
+	Sender  User         `gorm:"foreignKey:SenderID;references:ID"`
+	Subject *User        `gorm:"foreignKey:SubjectID;references:ID"`
+	Media   []MediaModel `gorm:"foreignKey:MessageID;references:ID"`
+}
+
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// BeforeCreate generates UUID and sets timestamp
+func (m *ConversationModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.CreatedAt == nil {
+		now := time.Now()
+		m.CreatedAt = &now
+	}
+	return nil
+}
+
+// BeforeCreate generates UUID and sets timestamp
+func (m *MessageModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	if m.Kind == "" {
+		m.Kind = string(message.KindText)
+	}
+	return nil
+}
+
+func (m *ConversationModel) toDomain() *message.Conversation {
+	c := &message.Conversation{
+		ID:          m.ID,
+		UnreadCount: m.UnreadCount,
+		CreatedAt:   m.CreatedAt,
+		Members:     make([]message.Member, len(m.Members)),
+	}
+	if m.LastMessageAt != nil {
+		c.LastMessageAt = *m.LastMessageAt
+	}
+	for i, member := range m.Members {
+		c.Members[i] = message.Member{
+			UserID:     member.UserID,
+			LastReadAt: member.LastReadAt,
+		}
+		if member.User.ID != "" {
+			c.Members[i].User = *member.User.toDomain()
+			c.Members[i].User.Password = ""
+		}
+	}
+	return c
+}
+
+func (m *MessageModel) toDomain() *message.Message {
+	createdAt := m.CreatedAt
+	msg := &message.Message{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		Kind:           message.Kind(m.Kind),
+		SenderID:       m.SenderID,
+		SubjectID:      m.SubjectID,
+		Body:           m.Body,
+		Media:          toDomainMediaList(m.Media),
+		CreatedAt:      &createdAt,
+	}
+	if m.Sender.ID != "" {
+		msg.Sender = *m.Sender.toDomain()
+		msg.Sender.Password = ""
+	}
+	if m.Subject != nil && m.Subject.ID != "" {
+		msg.Subject = *m.Subject.toDomain()
+		msg.Subject.Password = ""
+	}
+	return msg
+}
+
+// directKey identifies conversation of two users regardless of who started it
+func directKey(userID, otherID string) string {
+	ids := []string{userID, otherID}
+	sort.Strings(ids)
+	return strings.Join(ids, ":")
+}
+
+// withConversation keeps conversations userID is a member of, counts
+// their unread messages and preloads members
+func withConversation(userID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Joins("JOIN conversation_member_models me ON me.conversation_id = conversation_models.id AND me.user_id = ?", userID).
+			Select(`conversation_models.*, (SELECT COUNT(*) FROM message_models
+				WHERE message_models.conversation_id = conversation_models.id
+				AND message_models.sender_id <> me.user_id
+				AND message_models.created_at > COALESCE(me.last_read_at, '-infinity')) AS unread_count`).
+			Preload("Members.User")
+	}
+}
+
+// withMessageDetails preloads sender, subject and media of messages
+func withMessageDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Sender").Preload("Subject").Scopes(withMedia)
+}
+
+// direct leaves out groups
+func direct(db *gorm.DB) *gorm.DB {
+	return db.Where("conversation_models.kind = ?", conversationDirect)
+}
+
+func (r *MessageRepository) GetOrCreateDirect(ctx context.Context, userID, otherID string) (*message.Conversation, error) {
+	key := directKey(userID, otherID)
+
+	// concurrent creation waits on the unique index and does nothing
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		conv := ConversationModel{Kind: conversationDirect, DirectKey: &key}
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "direct_key"}},
+			DoNothing: true,
+		}).Create(&conv)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		now := time.Now()
+		return tx.Create(&[]ConversationMemberModel{
+			{ConversationID: conv.ID, UserID: userID, JoinedAt: &now},
+			{ConversationID: conv.ID, UserID: otherID, JoinedAt: &now},
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var model ConversationModel
+	err = r.db.WithContext(ctx).
+		Scopes(withConversation(userID), direct).
+		Where("conversation_models.direct_key = ?", key).
+		First(&model).Error
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := r.withLastMessages(ctx, &model)
+	if err != nil {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+func (r *MessageRepository) Get(ctx context.Context, userID, id string) (*message.Conversation, error) {
+	var model ConversationModel
+	err := r.db.WithContext(ctx).
+		Scopes(withConversation(userID), direct).
+		Where("conversation_models.id = ?", id).
+		First(&model).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, message.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := r.withLastMessages(ctx, &model)
+	if err != nil {
+		return nil, err
+	}
+	return list[0], nil
+}
+
+func (r *MessageRepository) List(ctx context.Context, userID, after string, limit int) ([]*message.Conversation, error) {
+	q := r.db.WithContext(ctx).
+		Scopes(withConversation(userID), direct).
+		Where("conversation_models.last_message_at IS NOT NULL")
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(conversation_models.last_message_at, conversation_models.id) < (?, ?)", t, id)
+	}
+
+	var models []*ConversationModel
+	err := q.Order("conversation_models.last_message_at DESC, conversation_models.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withLastMessages(ctx, models...)
+}
+
+// withLastMessages converts conversations and loads their last messages
+func (r *MessageRepository) withLastMessages(ctx context.Context, models ...*ConversationModel) ([]*message.Conversation, error) {
+	last, err := lastMessages(r.db.WithContext(ctx), models)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*message.Conversation, len(models))
+	for i, m := range models {
+		list[i] = m.toDomain()
+		list[i].LastMessage = last[m.ID]
+	}
+	return list, nil
+}
+
+// lastMessages loads the last message of every conversation, keyed by conversation ID
+func lastMessages(db *gorm.DB, models []*ConversationModel) (map[string]*message.Message, error) {
+	last := make(map[string]*message.Message, len(models))
+	if len(models) == 0 {
+		return last, nil
+	}
+
+	ids := make([]string, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+	}
+
+	latest := db.Session(&gorm.Session{NewDB: true}).
+		Model(&MessageModel{}).
+		Select("DISTINCT ON (conversation_id) *").
+		Where("conversation_id IN ?", ids).
+		Order("conversation_id, created_at DESC, id DESC")
+
+	var messages []*MessageModel
+	err := db.Table("(?) AS message_models", latest).
+		Scopes(withMessageDetails).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range messages {
+		last[m.ConversationID] = m.toDomain()
+	}
+	return last, nil
+}
+
+func (r *MessageRepository) CreateMessage(ctx context.Context, m *message.Message) error {
+	model := &MessageModel{
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		if err := attachMessageMedia(tx, model.ID, m.SenderID, m.MediaIDs); err != nil {
+			return err
+		}
+
+		// clocks of replicas differ, activity never moves back. GREATEST
+		// ignores nulls
+		err := tx.Model(&ConversationModel{}).
+			Where("id = ?", m.ConversationID).
+			Update("last_message_at", gorm.Expr("GREATEST(last_message_at, ?)", model.CreatedAt)).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&ConversationMemberModel{}).
+			Where("conversation_id = ? AND user_id = ?", m.ConversationID, m.SenderID).
+			Update("last_read_at", gorm.Expr("GREATEST(last_read_at, ?)", model.CreatedAt)).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	var saved MessageModel
+	err = r.db.WithContext(ctx).
+		Scopes(withMessageDetails).
+		Where("id = ?", model.ID).
+		First(&saved).Error
+	if err != nil {
+		return err
+	}
+
+	*m = *saved.toDomain()
+	return nil
+}
+
+// attachMessageMedia links uploaded media of ownerID to a message, like
+// attachMedia does for posts. Only private media can be attached
+func attachMessageMedia(tx *gorm.DB, messageID, ownerID string, ids []string) error {
+	for i, id := range ids {
+		res := tx.Model(&MediaModel{}).
+			Where("id = ? AND owner_id = ? AND post_id IS NULL AND message_id IS NULL AND private", id, ownerID).
+			Updates(map[string]interface{}{
+				"message_id": messageID,
+				"position":   i,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return message.ErrMediaNotAvailable
+		}
+	}
+	return nil
+}
+
+func (r *MessageRepository) GetMessages(ctx context.Context, conversationID string, since *time.Time, after string, limit int) ([]*message.Message, error) {
+	q := r.db.WithContext(ctx).
+		Scopes(withMessageDetails).
+		Where("conversation_id = ?", conversationID)
+
+	if since != nil {
+		q = q.Where("created_at >= ?", *since)
+	}
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(created_at, id) < (?, ?)", t, id)
+	}
+
+	var models []*MessageModel
+	err := q.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*message.Message, len(models))
+	for i, m := range models {
+		messages[i] = m.toDomain()
+	}
+	return messages, nil
+}
+
+func (r *MessageRepository) MarkRead(ctx context.Context, userID, conversationID string) error {
+	latest := r.db.Session(&gorm.Session{NewDB: true}).
+		Model(&MessageModel{}).
+		Select("MAX(created_at)").
+		Where("conversation_id = ?", conversationID)
+
+	res := r.db.WithContext(ctx).
+		Model(&ConversationMemberModel{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Update("last_read_at", gorm.Expr("GREATEST(last_read_at, (?))", latest))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return message.ErrNotFound
+	}
+	return nil
+}
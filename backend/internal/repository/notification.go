@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationModel is a group of events, only one unread notification
+// per group key can exist for a recipient
+type NotificationModel struct {
+	ID          string  `gorm:"primaryKey;not null"`
+	RecipientID string  `gorm:"uniqueIndex:idx_notification_open,where:read_at IS NULL;index:idx_notification_latest,priority:1;not null"`
+	Type        string  `gorm:"not null"`
+	GroupKey    string  `gorm:"uniqueIndex:idx_notification_open,where:read_at IS NULL;not null"`
+	PostID      *string `gorm:"index"`
+	ActorsCount int     `gorm:"not null;default:0"`
+	CreatedAt   *time.Time
+	LatestAt    time.Time `gorm:"index:idx_notification_latest,priority:2;not null"`
+	ReadAt      *time.Time
+
+	Actors []NotificationActorModel `gorm:"foreignKey:NotificationID;references:ID"`
+}
+
+// NotificationActorModel is an actor of a notification, repeated events
+// of the same actor only move CreatedAt
+type NotificationActorModel struct {
+	NotificationID string    `gorm:"primaryKey;not null"`
+	ActorID        string    `gorm:"primaryKey;index;not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+
+	Actor User `gorm:"foreignKey:ActorID;references:ID"`
+}
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (m *NotificationModel) toDomain() *notification.Notification {
+	n := &notification.Notification{
+		ID:          m.ID,
+		RecipientID: m.RecipientID,
+		Type:        notification.Type(m.Type),
+		PostID:      m.PostID,
+		ActorsCount: m.ActorsCount,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.LatestAt,
+		ReadAt:      m.ReadAt,
+		Actors:      []user.User{},
+	}
+	for _, a := range m.Actors {
+		// deleted users are still counted but not listed
+		if a.Actor.ID != "" {
+			n.Actors = append(n.Actors, *a.Actor.toDomain())
+		}
+	}
+	return n
+}
+
+// Add upserts the unread notification of the event group. The upsert
+// locks the notification row, so concurrent events count actors correctly
+func (r *NotificationRepository) Add(ctx context.Context, e *notification.Event) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var id string
+		err := tx.Raw(`INSERT INTO notification_models
+			(id, recipient_id, type, group_key, post_id, actors_count, created_at, latest_at)
+			VALUES (?, ?, ?, ?, ?, 0, ?, ?)
+			ON CONFLICT (recipient_id, group_key) WHERE read_at IS NULL
+			DO UPDATE SET latest_at = GREATEST(notification_models.latest_at, EXCLUDED.latest_at)
+			RETURNING id`,
+			uuid.NewString(), e.RecipientID, string(e.Type), e.GroupKey(), e.PostID, e.At, e.At,
+		).Scan(&id).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO notification_actor_models (notification_id, actor_id, created_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = EXCLUDED.created_at`,
+			id, e.ActorID, e.At,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`UPDATE notification_models SET actors_count =
+			(SELECT COUNT(*) FROM notification_actor_models WHERE notification_id = ?)
+			WHERE id = ?`, id, id).Error
+	})
+}
+
+// visibleNotifications leaves out notifications about deleted posts
+func visibleNotifications(db *gorm.DB, recipientID string) *gorm.DB {
+	return db.Model(&NotificationModel{}).
+		Joins("LEFT JOIN post_models ON post_models.id = notification_models.post_id").
+		Where("notification_models.recipient_id = ?", recipientID).
+		Where("notification_models.post_id IS NULL OR post_models.deleted_at IS NULL")
+}
+
+func (r *NotificationRepository) List(ctx context.Context, recipientID, after string, limit int) ([]*notification.Notification, error) {
+	q := visibleNotifications(r.db.WithContext(ctx), recipientID)
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(notification_models.latest_at, notification_models.id) < (?, ?)", t, id)
+	}
+
+	var models []*NotificationModel
+	err := q.Order("notification_models.latest_at DESC, notification_models.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadActors(ctx, models); err != nil {
+		return nil, err
+	}
+
+	list := make([]*notification.Notification, len(models))
+	for i, m := range models {
+		list[i] = m.toDomain()
+	}
+	return list, nil
+}
+
+// loadActors loads the latest MaxActors actors of every notification,
+// a notification about a popular post can have thousands of them
+func (r *NotificationRepository) loadActors(ctx context.Context, models []*NotificationModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(models))
+	byID := make(map[string]*NotificationModel, len(models))
+	for i, m := range models {
+		ids[i] = m.ID
+		byID[m.ID] = m
+	}
+
+	ranked := r.db.Session(&gorm.Session{NewDB: true}).
+		Model(&NotificationActorModel{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY notification_id ORDER BY created_at DESC, actor_id) AS rn").
+		Where("notification_id IN ?", ids)
+
+	var actors []NotificationActorModel
+	err := r.db.WithContext(ctx).
+		Table("(?) AS notification_actor_models", ranked).
+		Preload("Actor").
+		Where("rn <= ?", notification.MaxActors).
+		Order("notification_id, created_at DESC, actor_id").
+		Find(&actors).Error
+	if err != nil {
+		return err
+	}
+
+	for _, a := range actors {
+		m := byID[a.NotificationID]
+		m.Actors = append(m.Actors, a)
+	}
+	return nil
+}
+
+func (r *NotificationRepository) UnreadCount(ctx context.Context, recipientID string) (int64, error) {
+	var count int64
+	err := visibleNotifications(r.db.WithContext(ctx), recipientID).
+		Where("notification_models.read_at IS NULL").
+		Count(&count).Error
+	return count, err
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, recipientID, id string, now time.Time) error {
+	var m NotificationModel
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND recipient_id = ?", id, recipientID).
+		First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notification.ErrNotFound
+	}
+	if err != nil || m.ReadAt != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&NotificationModel{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", now).Error
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, recipientID string, now time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&NotificationModel{}).
+		Where("recipient_id = ? AND read_at IS NULL", recipientID).
+		Update("read_at", now).Error
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PollModel is a poll of a post, a post holds at most one
+type PollModel struct {
+	PostID   string    `gorm:"primaryKey;not null"`
+	Multiple bool      `gorm:"not null;default:false"`
+	ClosesAt time.Time `gorm:"not null"`
+
+	// computed by withPoll, not stored
+	VotersCount int `gorm:"->;-:migration"`
+
+	Options []PollOptionModel `gorm:"foreignKey:PostID;references:PostID"`
+}
+
+type PollOptionModel struct {
+	PostID   string `gorm:"primaryKey;not null"`
+	Position int    `gorm:"primaryKey;not null"`
+	Text     string `gorm:"not null"`
+
+	// computed by withPoll, not stored
+	VotesCount int `gorm:"->;-:migration"`
+}
+
+// PollBallotModel is a vote of a user, its primary key lets a user vote
+// only once in a poll
+type PollBallotModel struct {
+	PostID    string `gorm:"primaryKey;not null"`
+	UserID    string `gorm:"primaryKey;not null;index"`
+	CreatedAt time.Time
+}
+
+// PollChoiceModel is an option picked in a ballot
+type PollChoiceModel struct {
+	PostID   string `gorm:"primaryKey;not null"`
+	UserID   string `gorm:"primaryKey;not null"`
+	Position int    `gorm:"primaryKey;not null"`
+}
+
+// withPoll preloads polls of posts with live tallies
+func withPoll(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Poll", func(db *gorm.DB) *gorm.DB {
+			voters := db.Session(&gorm.Session{NewDB: true}).
+				Model(&PollBallotModel{}).
+				Select("COUNT(*)").
+				Where("poll_ballot_models.post_id = poll_models.post_id")
+
+			return db.Select("poll_models.*, (?) AS voters_count", voters)
+		}).
+		Preload("Poll.Options", func(db *gorm.DB) *gorm.DB {
+			votes := db.Session(&gorm.Session{NewDB: true}).
+				Model(&PollChoiceModel{}).
+				Select("COUNT(*)").
+				Where("poll_choice_models.post_id = poll_option_models.post_id AND poll_choice_models.position = poll_option_models.position")
+
+			return db.Select("poll_option_models.*, (?) AS votes_count", votes).Order("position ASC")
+		})
+}
+
+func toDomainPoll(m *PollModel) *post.Poll {
+	if m == nil {
+		return nil
+	}
+
+	poll := &post.Poll{
+		Multiple:    m.Multiple,
+		ClosesAt:    m.ClosesAt,
+		VotersCount: m.VotersCount,
+		Options:     make([]post.PollOption, len(m.Options)),
+	}
+	for i, o := range m.Options {
+		poll.Options[i] = post.PollOption{
+			Position:   o.Position,
+			Text:       o.Text,
+			VotesCount: o.VotesCount,
+		}
+	}
+
+	return poll
+}
+
+// createPoll saves poll of a new post
+func createPoll(tx *gorm.DB, postID string, poll *post.Poll) error {
+	if poll == nil {
+		return nil
+	}
+
+	model := PollModel{
+		PostID:   postID,
+		Multiple: poll.Multiple,
+		ClosesAt: poll.ClosesAt,
+	}
+	if err := tx.Omit("Options").Create(&model).Error; err != nil {
+		return err
+	}
+
+	options := make([]PollOptionModel, len(poll.Options))
+	for i, o := range poll.Options {
+		options[i] = PollOptionModel{
+			PostID:   postID,
+			Position: o.Position,
+			Text:     o.Text,
+		}
+	}
+
+	return tx.Create(&options).Error
+}
+
+// Vote saves ballot of userID with its choices. Ballot insert is skipped
+// when the user already voted, which is reported as ErrAlreadyVoted
+func (r *PostRepository) Vote(ctx context.Context, postID, userID string, choices []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&PollBallotModel{PostID: postID, UserID: userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return post.ErrAlreadyVoted
+		}
+
+		models := make([]PollChoiceModel, len(choices))
+		for i, c := range choices {
+			models[i] = PollChoiceModel{PostID: postID, UserID: userID, Position: c}
+		}
+
+		return tx.Create(&models).Error
+	})
+}
+
+func (r *PostRepository) PollChoices(ctx context.Context, userID string, postIDs []string) (map[string][]int, error) {
+	choices := map[string][]int{}
+	if len(postIDs) == 0 {
+		return choices, nil
+	}
+
+	var models []PollChoiceModel
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Order("position ASC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range models {
+		choices[m.PostID] = append(choices[m.PostID], m.Position)
+	}
+	return choices, nil
+}
@@ -2,25 +2,63 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/critiq17/critiqal-site/internal/domain/post"
 	"github.com/critiq17/critiqal-site/internal/domain/user"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostModel struct {
 	ID          string `gorm:"primaryKey;not null"`
-	OwnerID     string `gorm:"index;not null"`
+	OwnerID     string `gorm:"index;index:idx_post_repost,unique,priority:1;not null"`
 	Title       *string
 	PhotoURL    *string
 	Description string `gorm:"not null"`
 	CreatedAt   *time.Time
 	DeletedAt   *time.Time `gorm:"index"`
 
-	Owner User `gorm:"foreignKey:OwnerID;references:ID" json:"author"`
+	// rendered markdown of Description, stored so listings don't render it
+	DescriptionHTML string `gorm:"not null;default:''"`
+
+	// a user can hold only one live repost of the same post
+	Kind       string  `gorm:"not null;default:post"`
+	OriginalID *string `gorm:"index;index:idx_post_repost,unique,where:kind = 'repost' AND deleted_at IS NULL,priority:2"`
+
+	// audience of the post, see visibleTo
+	Visibility string `gorm:"not null;default:public;index"`
+
+	// drafts and scheduled posts are visible only to their owner
+	Status    string     `gorm:"not null;default:published;index"`
+	PublishAt *time.Time `gorm:"index"`
+
+	// set on edits of published posts, previous versions are revisions
+	EditedAt *time.Time
+
+	// pinned posts go first on the owner's profile
+	PinnedAt *time.Time `gorm:"index"`
+
+	ContentWarning *string
+	Sensitive      bool `gorm:"not null;default:false"`
+
+	// deduplicated views, incremented in batches by ViewCounter
+	ViewsCount int64 `gorm:"not null;default:0"`
+
+	// computed by withPostCounts, not stored
+	CommentsCount  int `gorm:"->;-:migration"`
+	RepostsCount   int `gorm:"->;-:migration"`
+	RevisionsCount int `gorm:"->;-:migration"`
+
+	Owner    User           `gorm:"foreignKey:OwnerID;references:ID" json:"author"`
+	Original *PostModel     `gorm:"foreignKey:OriginalID;references:ID"`
+	Tags     []PostTagModel `gorm:"foreignKey:PostID;references:ID"`
+	Mentions []MentionModel `gorm:"foreignKey:PostID;references:ID"`
+	Media    []MediaModel   `gorm:"foreignKey:PostID;references:ID"`
+	Poll     *PollModel     `gorm:"foreignKey:PostID;references:ID"`
 }
 
 type PostRepository struct {
@@ -41,6 +79,36 @@ func toDomainPost(p *PostModel) *post.Post {
 		Description: p.Description,
 		CreatedAt:   p.CreatedAt,
 		DeletedAt:   p.DeletedAt,
+		Kind:        post.Kind(p.Kind),
+		OriginalID:  p.OriginalID,
+		Visibility:  post.Visibility(p.Visibility),
+		Status:      post.Status(p.Status),
+		PublishAt:   p.PublishAt,
+		Tags:        toDomainTags(p.Tags),
+		Mentions:    toDomainMentions(p.Mentions),
+		Media:       toDomainMediaList(p.Media),
+
+		DescriptionHTML: p.DescriptionHTML,
+
+		CommentsCount: p.CommentsCount,
+		RepostsCount:  p.RepostsCount,
+
+		EditedAt:       p.EditedAt,
+		RevisionsCount: p.RevisionsCount,
+
+		PinnedAt: p.PinnedAt,
+
+		ContentWarning: p.ContentWarning,
+		Sensitive:      &p.Sensitive,
+
+		ViewsCount: &p.ViewsCount,
+
+		Poll: toDomainPoll(p.Poll),
+	}
+
+	// Deleted originals are left out, post keeps only the reference
+	if p.Original != nil && p.Original.DeletedAt == nil {
+		domainPost.Original = toDomainPost(p.Original)
 	}
 
 	// Map Owner if exists
@@ -52,6 +120,8 @@ func toDomainPost(p *PostModel) *post.Post {
 			FirstName: p.Owner.FirstName,
 			LastName:  p.Owner.LastName,
 			PhotoURL:  p.Owner.PhotoURL,
+
+			PhotoThumbnails: p.Owner.PhotoThumbnails,
 		}
 	}
 
@@ -69,7 +139,7 @@ func toDomainPosts(models []*PostModel) []*post.Post {
 
 // toModelPost converts domain model to database model
 func toModelPost(p *post.Post) *PostModel {
-	return &PostModel{
+	model := &PostModel{
 		ID:          p.ID,
 		OwnerID:     p.OwnerID,
 		Title:       p.Title,
@@ -77,9 +147,108 @@ func toModelPost(p *post.Post) *PostModel {
 		Description: p.Description,
 		CreatedAt:   p.CreatedAt,
 		DeletedAt:   p.DeletedAt,
+		Kind:        string(p.Kind),
+		OriginalID:  p.OriginalID,
+		Visibility:  string(p.Visibility),
+		Status:      string(p.Status),
+		PublishAt:   p.PublishAt,
+
+		DescriptionHTML: p.DescriptionHTML,
+
+		Sensitive: p.IsSensitive(),
+	}
+	if p.ContentWarning != nil {
+		model.ContentWarning = nullableString(*p.ContentWarning)
+	}
+
+	return model
+}
+
+// withPostCounts selects aggregated counters alongside post columns
+func withPostCounts(db *gorm.DB) *gorm.DB {
+	newDB := db.Session(&gorm.Session{NewDB: true})
+
+	comments := newDB.Model(&CommentModel{}).
+		Select("COUNT(*)").
+		Where("comment_models.post_id = post_models.id AND comment_models.deleted_at IS NULL")
+
+	reposts := newDB.Table("post_models AS reposts").
+		Select("COUNT(*)").
+		Where("reposts.original_id = post_models.id AND reposts.kind = ? AND reposts.deleted_at IS NULL", post.KindRepost)
+
+	revisions := newDB.Model(&PostRevisionModel{}).
+		Select("COUNT(*)").
+		Where("post_revision_models.post_id = post_models.id")
+
+	return db.Select("post_models.*, (?) AS comments_count, (?) AS reposts_count, (?) AS revisions_count", comments, reposts, revisions)
+}
+
+// withOriginal preloads referenced post of reposts and quotes
+func withOriginal(db *gorm.DB) *gorm.DB {
+	return db.Preload("Original", func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(withPostCounts, withDetails)
+	})
+}
+
+// withDetails preloads owner and everything displayed along with a post
+func withDetails(db *gorm.DB) *gorm.DB {
+	return db.Preload("Owner").Scopes(withTags, withMentions, withMedia, withPoll)
+}
+
+// published leaves out drafts and scheduled posts
+func published(db *gorm.DB) *gorm.DB {
+	return db.Where("post_models.status = ?", post.StatusPublished)
+}
+
+// visibleTo leaves out posts viewerID is not allowed to see. Owners see
+// all their posts, followers-only posts are shown to followers and, like
+// mentioned-only posts, to mentioned users
+func visibleTo(viewerID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		newDB := db.Session(&gorm.Session{NewDB: true})
+
+		follows := newDB.Model(&FollowModel{}).
+			Select("1").
+			Where("follow_models.follower_id = ? AND follow_models.followee_id = post_models.owner_id", viewerID)
+
+		mentioned := newDB.Model(&MentionModel{}).
+			Select("1").
+			Where("mention_models.post_id = post_models.id AND mention_models.user_id = ?", viewerID)
+
+		return db.Where(
+			"post_models.owner_id = ? OR post_models.visibility IN ? OR "+
+				"(post_models.visibility = ? AND EXISTS (?)) OR "+
+				"(post_models.visibility IN ? AND EXISTS (?))",
+			viewerID,
+			[]post.Visibility{post.VisibilityPublic, post.VisibilityUnlisted},
+			post.VisibilityFollowers, follows,
+			[]post.Visibility{post.VisibilityFollowers, post.VisibilityMentioned}, mentioned,
+		)
 	}
 }
 
+// listed leaves out unlisted posts, it is used by global listings. Reposts
+// and quotes of unlisted posts are left out too, they embed the original
+func listed(db *gorm.DB) *gorm.DB {
+	unlistedOriginal := db.Session(&gorm.Session{NewDB: true}).
+		Table("post_models AS originals").
+		Select("1").
+		Where("originals.id = post_models.original_id AND originals.visibility = ?", post.VisibilityUnlisted)
+
+	return db.Where("post_models.visibility <> ? AND NOT EXISTS (?)", post.VisibilityUnlisted, unlistedOriginal)
+}
+
+// withoutOrphanReposts hides pure reposts whose original was deleted from listings
+func withoutOrphanReposts(db *gorm.DB) *gorm.DB {
+	return db.Where("post_models.kind <> ? OR EXISTS (?)",
+		post.KindRepost,
+		db.Session(&gorm.Session{NewDB: true}).
+			Table("post_models AS originals").
+			Select("1").
+			Where("originals.id = post_models.original_id AND originals.deleted_at IS NULL"),
+	)
+}
+
 // BeforeCreate generates UUID and sets timestamp
 func (p *PostModel) BeforeCreate(tx *gorm.DB) (err error) {
 	if p.ID == "" {
@@ -89,10 +258,19 @@ func (p *PostModel) BeforeCreate(tx *gorm.DB) (err error) {
 	if p.CreatedAt == nil {
 		p.CreatedAt = &now
 	}
+	if p.Kind == "" {
+		p.Kind = string(post.KindPost)
+	}
+	if p.Status == "" {
+		p.Status = string(post.StatusPublished)
+	}
+	if p.Visibility == "" {
+		p.Visibility = string(post.VisibilityPublic)
+	}
 	return nil
 }
 
-// Create inserts a new post using userID directly from JWT
+// Create inserts a new post using userID directly from JWT, along with its tags
 func (r *PostRepository) Create(ctx context.Context, p *post.Post) error {
 	model := toModelPost(p)
 
@@ -105,7 +283,26 @@ func (r *PostRepository) Create(ctx context.Context, p *post.Post) error {
 		model.CreatedAt = &now
 	}
 
-	err := r.db.WithContext(ctx).Create(model).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(model).Error; err != nil {
+			return err
+		}
+		if err := syncPostTags(tx, model.ID, p.Tags); err != nil {
+			return err
+		}
+		if err := syncPostMentions(tx, model.ID, p.Mentions); err != nil {
+			return err
+		}
+		if err := attachMedia(tx, model.ID, model.OwnerID, p.MediaIDs); err != nil {
+			return err
+		}
+		if len(p.SensitiveMediaIDs) > 0 {
+			if err := markSensitiveMedia(tx, model.ID, p.SensitiveMediaIDs); err != nil {
+				return err
+			}
+		}
+		return createPoll(tx, model.ID, p.Poll)
+	})
 	if err != nil {
 		return err
 	}
@@ -116,14 +313,17 @@ func (r *PostRepository) Create(ctx context.Context, p *post.Post) error {
 	return nil
 }
 
-// Get retrieves a single post by ID
-func (r *PostRepository) Get(ctx context.Context, id string) (*post.Post, error) {
+// Get retrieves a single published post by ID if viewerID can see it
+func (r *PostRepository) Get(ctx context.Context, viewerID, id string) (*post.Post, error) {
 	var model PostModel
 	err := r.db.WithContext(ctx).
-		Preload("Owner").
+		Scopes(withPostCounts, withOriginal, withDetails, published, visibleTo(viewerID)).
 		Where("id = ? AND deleted_at IS NULL", id).
 		First(&model).Error
 
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, post.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +331,8 @@ func (r *PostRepository) Get(ctx context.Context, id string) (*post.Post, error)
 	return toDomainPost(&model), nil
 }
 
-// Update modifies an existing post
+// Update modifies an existing post. Tags and mentions are replaced when
+// they are not nil. Changed content of published posts is saved as revision
 func (r *PostRepository) Update(ctx context.Context, id string, p *post.Post) error {
 	updates := map[string]interface{}{}
 
@@ -143,33 +344,165 @@ func (r *PostRepository) Update(ctx context.Context, id string, p *post.Post) er
 	}
 	if p.Description != "" {
 		updates["description"] = p.Description
+		updates["description_html"] = p.DescriptionHTML
+	}
+	// empty warning removes it
+	if p.ContentWarning != nil {
+		updates["content_warning"] = nullableString(*p.ContentWarning)
+	}
+	if p.Sensitive != nil {
+		updates["sensitive"] = *p.Sensitive
+	}
+	// status change, created_at is moved when a draft gets published
+	if p.Status != "" {
+		updates["status"] = p.Status
+		updates["publish_at"] = p.PublishAt
+		if p.CreatedAt != nil {
+			updates["created_at"] = p.CreatedAt
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current PostModel
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NULL", id).
+			First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return post.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if current.Status == string(post.StatusPublished) && contentChanged(&current, p) {
+			now := time.Now()
+			if err := saveRevision(tx, &current, now); err != nil {
+				return err
+			}
+			updates["edited_at"] = now
+		}
+
+		if len(updates) > 0 {
+			err := tx.Model(&PostModel{}).
+				Where("id = ? AND deleted_at IS NULL", id).
+				Updates(updates).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if p.Tags != nil {
+			if err := syncPostTags(tx, id, p.Tags); err != nil {
+				return err
+			}
+		}
+		if p.SensitiveMediaIDs != nil {
+			if err := markSensitiveMedia(tx, id, p.SensitiveMediaIDs); err != nil {
+				return err
+			}
+		}
+		if p.Mentions != nil {
+			return syncPostMentions(tx, id, p.Mentions)
+		}
+		return nil
+	})
+}
+
+// contentChanged reports whether update replaces visible content of a post
+func contentChanged(current *PostModel, p *post.Post) bool {
+	changed := func(old, new *string) bool {
+		return new != nil && (old == nil || *old != *new)
 	}
 
+	return changed(current.Title, p.Title) ||
+		changed(current.PhotoURL, p.PhotoURL) ||
+		(p.Description != "" && p.Description != current.Description)
+}
+
+// Delete moves a post to trash, deleted posts are unpinned. Everything
+// else is kept until the post is purged, so it can be restored
+func (r *PostRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).
 		Model(&PostModel{}).
 		Where("id = ? AND deleted_at IS NULL", id).
-		Updates(updates).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"pinned_at":  nil,
+		}).
 		Error
 }
 
-// Delete soft deletes a post
-func (r *PostRepository) Delete(ctx context.Context, id string) error {
-	now := time.Now()
+// Pin pins post to profile of its owner. Owner row is locked while
+// pinned posts are counted, so concurrent pins can't exceed max
+func (r *PostRepository) Pin(ctx context.Context, ownerID, id string, max int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var owner User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", ownerID).
+			First(&owner).Error
+		if err != nil {
+			return err
+		}
+
+		var target PostModel
+		err = tx.Where("id = ? AND owner_id = ? AND status = ? AND deleted_at IS NULL", id, ownerID, post.StatusPublished).
+			First(&target).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return post.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if target.PinnedAt != nil {
+			return nil
+		}
+
+		var pinned int64
+		err = tx.Model(&PostModel{}).
+			Where("owner_id = ? AND pinned_at IS NOT NULL AND deleted_at IS NULL", ownerID).
+			Count(&pinned).Error
+		if err != nil {
+			return err
+		}
+		if pinned >= int64(max) {
+			return post.ErrTooManyPinned
+		}
+
+		return tx.Model(&PostModel{}).
+			Where("id = ?", id).
+			Update("pinned_at", time.Now()).
+			Error
+	})
+}
+
+func (r *PostRepository) Unpin(ctx context.Context, ownerID, id string) error {
 	return r.db.WithContext(ctx).
 		Model(&PostModel{}).
-		Where("id = ? AND deleted_at IS NULL", id).
-		Update("deleted_at", now).
+		Where("id = ? AND owner_id = ?", id, ownerID).
+		Update("pinned_at", nil).
+		Error
+}
+
+// SetDescriptionHTML stores rendered description of a post
+func (r *PostRepository) SetDescriptionHTML(ctx context.Context, id, html string) error {
+	return r.db.WithContext(ctx).
+		Model(&PostModel{}).
+		Where("id = ?", id).
+		Update("description_html", html).
 		Error
 }
 
-// GetPostsByUserID retrieves all posts by userID
-func (r *PostRepository) GetPostsByUserID(ctx context.Context, userID string) ([]*post.Post, error) {
+// GetPostsByUserID retrieves all posts by userID which viewerID can see,
+// unlisted posts included
+func (r *PostRepository) GetPostsByUserID(ctx context.Context, viewerID, userID string) ([]*post.Post, error) {
 	var models []*PostModel
 
 	err := r.db.WithContext(ctx).
-		Preload("Owner").
+		Scopes(withPostCounts, withOriginal, withDetails, withoutOrphanReposts, published, visibleTo(viewerID)).
 		Where("owner_id = ? AND deleted_at IS NULL", userID).
-		Order("created_at DESC").
+		Order("pinned_at DESC NULLS LAST, created_at DESC").
 		Find(&models).Error
 
 	if err != nil {
@@ -180,38 +513,147 @@ func (r *PostRepository) GetPostsByUserID(ctx context.Context, userID string) ([
 }
 
 // GetRecent retrieves most recent posts
-// В repository/post.go - GetRecent
-func (r *PostRepository) GetRecent(ctx context.Context, limit int) ([]*post.Post, error) {
+func (r *PostRepository) GetRecent(ctx context.Context, viewerID string, limit int) ([]*post.Post, error) {
 	var models []*PostModel
 
-	fmt.Printf("GetRecent called with limit=%d\n", limit)
-
 	err := r.db.WithContext(ctx).
-		Debug().
-		Preload("Owner").
+		Scopes(withPostCounts, withOriginal, withDetails, withoutOrphanReposts, published, visibleTo(viewerID), listed).
 		Where("deleted_at IS NULL").
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&models).Error
 
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
 		return nil, err
 	}
 
-	fmt.Printf("Found %d models\n", len(models))
-	for i, m := range models {
-		fmt.Printf("  Model %d: ID=%s, OwnerID=%s, Owner.ID='%s', Owner.Username='%s'\n",
-			i, m.ID, m.OwnerID, m.Owner.ID, m.Owner.Username)
+	return toDomainPosts(models), nil
+}
+
+// FindRepost retrieves live repost of originalID made by ownerID
+func (r *PostRepository) FindRepost(ctx context.Context, ownerID, originalID string) (*post.Post, error) {
+	var model PostModel
+	err := r.db.WithContext(ctx).
+		Scopes(withPostCounts, withOriginal, withDetails).
+		Where("owner_id = ? AND original_id = ? AND kind = ? AND deleted_at IS NULL", ownerID, originalID, post.KindRepost).
+		First(&model).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, post.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	posts := toDomainPosts(models)
-	fmt.Printf("Converted to %d domain posts\n", len(posts))
+	return toDomainPost(&model), nil
+}
+
+// DeleteRepost soft deletes repost of originalID made by ownerID
+func (r *PostRepository) DeleteRepost(ctx context.Context, ownerID, originalID string) error {
+	return r.db.WithContext(ctx).
+		Model(&PostModel{}).
+		Where("owner_id = ? AND original_id = ? AND kind = ? AND deleted_at IS NULL", ownerID, originalID, post.KindRepost).
+		Update("deleted_at", time.Now()).
+		Error
+}
+
+// findPage retrieves posts matched by q, newest first, starting after cursor
+func (r *PostRepository) findPage(q *gorm.DB, after string, limit int) ([]*post.Post, error) {
+	var models []*PostModel
+
+	q = q.Scopes(withPostCounts, withOriginal, withDetails)
 
-	if len(posts) > 0 {
-		fmt.Printf("  First post Owner: ID='%s', Username='%s'\n",
-			posts[0].Owner.ID, posts[0].Owner.Username)
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(post_models.created_at, post_models.id) < (?, ?)", t, id)
+	}
+
+	err := q.Order("post_models.created_at DESC, post_models.id DESC").
+		Limit(limit).
+		Find(&models).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainPosts(models), nil
+}
+
+// GetDraft retrieves draft or scheduled post of ownerID
+func (r *PostRepository) GetDraft(ctx context.Context, ownerID, id string) (*post.Post, error) {
+	var model PostModel
+	err := r.db.WithContext(ctx).
+		Scopes(withPostCounts, withOriginal, withDetails).
+		Where("id = ? AND owner_id = ? AND status <> ? AND deleted_at IS NULL", id, ownerID, post.StatusPublished).
+		First(&model).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, post.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainPost(&model), nil
+}
+
+// GetDrafts retrieves drafts and scheduled posts of ownerID, newest first
+func (r *PostRepository) GetDrafts(ctx context.Context, ownerID, after string, limit int) ([]*post.Post, error) {
+	q := r.db.WithContext(ctx).
+		Where("post_models.owner_id = ? AND post_models.status <> ? AND post_models.deleted_at IS NULL", ownerID, post.StatusPublished)
+
+	return r.findPage(q, after, limit)
+}
+
+// GetPublished retrieves published posts by IDs without checking visibility
+func (r *PostRepository) GetPublished(ctx context.Context, ids []string) ([]*post.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var models []*PostModel
+	err := r.db.WithContext(ctx).
+		Scopes(withPostCounts, withOriginal, withDetails, published).
+		Where("post_models.id IN ? AND post_models.deleted_at IS NULL", ids).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainPosts(models), nil
+}
+
+// PublishDue claims due scheduled posts with SKIP LOCKED and publishes
+// them in the same transaction. Status is checked again on update, so
+// concurrent schedulers never publish the same post twice
+func (r *PostRepository) PublishDue(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&PostModel{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND publish_at <= ? AND deleted_at IS NULL", post.StatusScheduled, now).
+			Order("publish_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		// published posts appear in feeds at their scheduled time
+		return tx.Model(&PostModel{}).
+			Where("id IN ? AND status = ?", ids, post.StatusScheduled).
+			Updates(map[string]interface{}{
+				"status":     post.StatusPublished,
+				"created_at": gorm.Expr("publish_at"),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return posts, nil
+	return ids, nil
 }
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/preview"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LinkPreviewModel caches unfurled links, shared by all posts with the same link
+type LinkPreviewModel struct {
+	URL         string    `gorm:"primaryKey;not null"`
+	Title       string    `gorm:"not null;default:''"`
+	Description string    `gorm:"not null;default:''"`
+	ImageURL    string    `gorm:"not null;default:''"`
+	SiteName    string    `gorm:"not null;default:''"`
+	FetchedAt   time.Time `gorm:"not null"`
+	Failed      bool      `gorm:"not null;default:false"`
+}
+
+type PreviewRepository struct {
+	db *gorm.DB
+}
+
+func NewPreviewRepository(db *gorm.DB) *PreviewRepository {
+	return &PreviewRepository{db: db}
+}
+
+func (r *PreviewRepository) GetByURLs(ctx context.Context, urls []string) (map[string]*preview.Preview, error) {
+	previews := map[string]*preview.Preview{}
+	if len(urls) == 0 {
+		return previews, nil
+	}
+
+	var models []LinkPreviewModel
+	if err := r.db.WithContext(ctx).Where("url IN ?", urls).Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	for _, m := range models {
+		previews[m.URL] = &preview.Preview{
+			URL:         m.URL,
+			Title:       m.Title,
+			Description: m.Description,
+			ImageURL:    m.ImageURL,
+			SiteName:    m.SiteName,
+			FetchedAt:   m.FetchedAt,
+			Failed:      m.Failed,
+		}
+	}
+	return previews, nil
+}
+
+func (r *PreviewRepository) Save(ctx context.Context, p *preview.Preview) error {
+	model := LinkPreviewModel{
+		URL:         p.URL,
+		Title:       p.Title,
+		Description: p.Description,
+		ImageURL:    p.ImageURL,
+		SiteName:    p.SiteName,
+		FetchedAt:   p.FetchedAt,
+		Failed:      p.Failed,
+	}
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&model).Error
+}
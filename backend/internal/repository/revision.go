@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostRevisionModel is a snapshot of post content taken before an edit
+type PostRevisionModel struct {
+	ID              string `gorm:"primaryKey;not null"`
+	PostID          string `gorm:"index;not null"`
+	Title           *string
+	PhotoURL        *string
+	Description     string `gorm:"not null"`
+	DescriptionHTML string `gorm:"not null;default:''"`
+	CreatedAt       *time.Time
+	ReplacedAt      *time.Time `gorm:"not null"`
+}
+
+// BeforeCreate generates UUID
+func (m *PostRevisionModel) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (m *PostRevisionModel) toDomain() *post.Revision {
+	return &post.Revision{
+		ID:              m.ID,
+		PostID:          m.PostID,
+		Title:           m.Title,
+		PhotoURL:        m.PhotoURL,
+		Description:     m.Description,
+		DescriptionHTML: m.DescriptionHTML,
+		CreatedAt:       m.CreatedAt,
+		ReplacedAt:      m.ReplacedAt,
+	}
+}
+
+// saveRevision stores current content of a post, the version was shown
+// since the previous edit or since the post was published
+func saveRevision(tx *gorm.DB, current *PostModel, replacedAt time.Time) error {
+	createdAt := current.CreatedAt
+	if current.EditedAt != nil {
+		createdAt = current.EditedAt
+	}
+
+	return tx.Create(&PostRevisionModel{
+		PostID:          current.ID,
+		Title:           current.Title,
+		PhotoURL:        current.PhotoURL,
+		Description:     current.Description,
+		DescriptionHTML: current.DescriptionHTML,
+		CreatedAt:       createdAt,
+		ReplacedAt:      &replacedAt,
+	}).Error
+}
+
+func (r *PostRepository) GetRevisions(ctx context.Context, postID string) ([]*post.Revision, error) {
+	var models []PostRevisionModel
+
+	err := r.db.WithContext(ctx).
+		Where("post_id = ?", postID).
+		Order("replaced_at DESC").
+		Find(&models).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*post.Revision, len(models))
+	for i := range models {
+		revisions[i] = models[i].toDomain()
+	}
+	return revisions, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"gorm.io/gorm"
+)
+
+// PostTagModel links a post with one of its normalized hashtags
+type PostTagModel struct {
+	PostID   string `gorm:"primaryKey;not null"`
+	Tag      string `gorm:"primaryKey;index;not null"`
+	Position int    `gorm:"not null;default:0"`
+}
+
+// withTags preloads tags of posts in order of appearance in the text
+func withTags(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
+
+func toDomainTags(models []PostTagModel) []string {
+	tags := make([]string, len(models))
+	for i, m := range models {
+		tags[i] = m.Tag
+	}
+	return tags
+}
+
+// syncPostTags replaces tags of a post with the given ones
+func syncPostTags(tx *gorm.DB, postID string, tags []string) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&PostTagModel{}).Error; err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	models := make([]PostTagModel, len(tags))
+	for i, tag := range tags {
+		models[i] = PostTagModel{PostID: postID, Tag: tag, Position: i}
+	}
+
+	return tx.Create(&models).Error
+}
+
+// GetByTag retrieves posts tagged with tag, newest first
+func (r *PostRepository) GetByTag(ctx context.Context, viewerID, tag, after string, limit int) ([]*post.Post, error) {
+	q := r.db.WithContext(ctx).
+		Scopes(published, visibleTo(viewerID), listed).
+		Where("post_models.deleted_at IS NULL").
+		Where("post_models.id IN (?)",
+			r.db.Model(&PostTagModel{}).Select("post_id").Where("tag = ?", tag),
+		)
+
+	return r.findPage(q, after, limit)
+}
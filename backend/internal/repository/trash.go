@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetDeleted retrieves posts of ownerID deleted after since, most
+// recently deleted first. Reposts are left out, they are undone by reposting
+func (r *PostRepository) GetDeleted(ctx context.Context, ownerID string, since time.Time, after string, limit int) ([]*post.Post, error) {
+	q := r.db.WithContext(ctx).
+		Scopes(withPostCounts, withOriginal, withDetails).
+		Where("post_models.owner_id = ? AND post_models.kind <> ? AND post_models.deleted_at > ?", ownerID, post.KindRepost, since)
+
+	if after != "" {
+		t, id, err := cursor.Decode(after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(post_models.deleted_at, post_models.id) < (?, ?)", t, id)
+	}
+
+	var models []*PostModel
+	err := q.Order("post_models.deleted_at DESC, post_models.id DESC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainPosts(models), nil
+}
+
+// Restore brings back post of ownerID deleted after since
+func (r *PostRepository) Restore(ctx context.Context, ownerID, id string, since time.Time) error {
+	res := r.db.WithContext(ctx).
+		Model(&PostModel{}).
+		Where("id = ? AND owner_id = ? AND kind <> ? AND deleted_at > ?", id, ownerID, post.KindRepost, since).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return post.ErrNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently deletes up to limit posts deleted before
+// before, along with everything attached to them. Reposts of purged posts
+// go with them, quotes keep their content and lose the reference.
+// Content of attached media is released and collected by media GC
+func (r *PostRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	var ids []string
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&PostModel{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("deleted_at < ?", before).
+			Order("deleted_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		var reposts []string
+		err = tx.Model(&PostModel{}).
+			Where("original_id IN ? AND kind = ? AND id NOT IN ?", ids, post.KindRepost, ids).
+			Pluck("id", &reposts).Error
+		if err != nil {
+			return err
+		}
+		all := append(append([]string{}, ids...), reposts...)
+
+		err = tx.Model(&PostModel{}).
+			Where("original_id IN ? AND id NOT IN ?", ids, all).
+			Update("original_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		notifications := tx.Session(&gorm.Session{NewDB: true}).
+			Model(&NotificationModel{}).
+			Select("id").
+			Where("post_id IN ?", all)
+		if err := tx.Where("notification_id IN (?)", notifications).Delete(&NotificationActorModel{}).Error; err != nil {
+			return err
+		}
+
+		// children go before the rows their foreign keys point to
+		dependents := []interface{}{
+			&PostTagModel{}, &MentionModel{}, &MediaModel{},
+			&PollChoiceModel{}, &PollBallotModel{}, &PollOptionModel{}, &PollModel{},
+			&PostRevisionModel{}, &BookmarkModel{}, &CommentModel{}, &NotificationModel{},
+		}
+		for _, m := range dependents {
+			if err := tx.Where("post_id IN ?", all).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("id IN ?", all).Delete(&PostModel{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/internal/domain/trending"
+	"gorm.io/gorm"
+)
+
+// TrendingPostModel is materialized ranking of posts, replaced on every computation
+type TrendingPostModel struct {
+	PostID     string    `gorm:"primaryKey;not null"`
+	Score      float64   `gorm:"not null;index"`
+	ComputedAt time.Time `gorm:"not null"`
+}
+
+// TrendingTagModel is materialized ranking of hashtags per window
+type TrendingTagModel struct {
+	WindowSeconds int64     `gorm:"primaryKey;not null"`
+	Tag           string    `gorm:"primaryKey;not null"`
+	Rank          int       `gorm:"not null"`
+	Posts         int       `gorm:"not null"`
+	Authors       int       `gorm:"not null"`
+	ComputedAt    time.Time `gorm:"not null"`
+}
+
+type TrendingRepository struct {
+	db *gorm.DB
+}
+
+func NewTrendingRepository(db *gorm.DB) *TrendingRepository {
+	return &TrendingRepository{db: db}
+}
+
+// trendingEligible limits ranking to posts anyone can see in listings,
+// p is the ranked post
+func trendingEligible(db *gorm.DB) *gorm.DB {
+	return db.Where("p.deleted_at IS NULL AND p.status = ? AND p.visibility = ? AND p.kind <> ?",
+		post.StatusPublished, post.VisibilityPublic, post.KindRepost)
+}
+
+func (r *TrendingRepository) Engagements(ctx context.Context, since time.Time) ([]trending.Engagement, error) {
+	type row struct {
+		PostID string
+		At     time.Time
+	}
+
+	var comments []row
+	err := r.db.WithContext(ctx).
+		Table("comment_models AS c").
+		Select("c.post_id AS post_id, c.created_at AS at").
+		Joins("JOIN post_models AS p ON p.id = c.post_id").
+		Where("c.created_at > ? AND c.deleted_at IS NULL AND c.author_id <> p.owner_id", since).
+		Scopes(trendingEligible).
+		Scan(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var reposts []row
+	err = r.db.WithContext(ctx).
+		Table("post_models AS s").
+		Select("s.original_id AS post_id, s.created_at AS at").
+		Joins("JOIN post_models AS p ON p.id = s.original_id").
+		Where("s.kind IN ? AND s.status = ? AND s.created_at > ? AND s.deleted_at IS NULL AND s.owner_id <> p.owner_id",
+			[]post.Kind{post.KindRepost, post.KindQuote}, post.StatusPublished, since).
+		Scopes(trendingEligible).
+		Scan(&reposts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]trending.Engagement, 0, len(comments)+len(reposts))
+	for _, c := range comments {
+		events = append(events, trending.Engagement{PostID: c.PostID, Kind: trending.EngagementComment, At: c.At})
+	}
+	for _, s := range reposts {
+		events = append(events, trending.Engagement{PostID: s.PostID, Kind: trending.EngagementRepost, At: s.At})
+	}
+	return events, nil
+}
+
+func (r *TrendingRepository) TagUses(ctx context.Context, since time.Time) ([]trending.TagUse, error) {
+	var uses []trending.TagUse
+	err := r.db.WithContext(ctx).
+		Table("post_tag_models AS t").
+		Select("t.tag AS tag, p.id AS post_id, p.owner_id AS owner_id, p.created_at AS at").
+		Joins("JOIN post_models AS p ON p.id = t.post_id").
+		Where("p.created_at > ?", since).
+		Scopes(trendingEligible).
+		Scan(&uses).Error
+
+	return uses, err
+}
+
+// trendingLockKey is the advisory lock key held while a ranking is replaced
+const trendingLockKey = 0x74726e64 // "trnd"
+
+// Replace swaps the stored ranking for a new one. Every replica computes
+// the ranking, when another one is replacing it at the same time this
+// one is skipped instead of colliding on primary keys
+func (r *TrendingRepository) Replace(ctx context.Context, posts []trending.PostScore, tags []trending.TagScore, computedAt time.Time) error {
+	postModels := make([]TrendingPostModel, len(posts))
+	for i, p := range posts {
+		postModels[i] = TrendingPostModel{PostID: p.PostID, Score: p.Score, ComputedAt: computedAt}
+	}
+
+	tagModels := make([]TrendingTagModel, len(tags))
+	rank := map[int64]int{}
+	for i, t := range tags {
+		window := int64(t.Window / time.Second)
+		rank[window]++
+		tagModels[i] = TrendingTagModel{
+			WindowSeconds: window,
+			Tag:           t.Tag,
+			Rank:          rank[window],
+			Posts:         t.Posts,
+			Authors:       t.Authors,
+			ComputedAt:    computedAt,
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", trendingLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		if err := tx.Where("1 = 1").Delete(&TrendingPostModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&TrendingTagModel{}).Error; err != nil {
+			return err
+		}
+		if len(postModels) > 0 {
+			if err := tx.CreateInBatches(postModels, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(tagModels) > 0 {
+			return tx.CreateInBatches(tagModels, 500).Error
+		}
+		return nil
+	})
+}
+
+func (r *TrendingRepository) GetTags(ctx context.Context, window time.Duration, limit int) ([]trending.TagScore, error) {
+	var models []TrendingTagModel
+	err := r.db.WithContext(ctx).
+		Where("window_seconds = ?", int64(window/time.Second)).
+		Order("rank ASC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]trending.TagScore, len(models))
+	for i, m := range models {
+		tags[i] = trending.TagScore{Window: window, Tag: m.Tag, Posts: m.Posts, Authors: m.Authors}
+	}
+	return tags, nil
+}
+
+// GetTrending retrieves ranked posts visible to viewerID, highest score first
+func (r *PostRepository) GetTrending(ctx context.Context, viewerID string, limit int) ([]*post.Post, error) {
+	var models []*PostModel
+	err := r.db.WithContext(ctx).
+		Scopes(withPostCounts, withOriginal, withDetails, published, visibleTo(viewerID), listed).
+		Joins("JOIN trending_post_models ON trending_post_models.post_id = post_models.id").
+		Where("post_models.deleted_at IS NULL").
+		Order("trending_post_models.score DESC, post_models.id ASC").
+		Limit(limit).
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainPosts(models), nil
+}
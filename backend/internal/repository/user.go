@@ -19,6 +19,12 @@ type User struct {
 	PhotoURL  string         `gorm:"default:null"`
 	CreatedAt int64          `gorm:"autoCreateTime:milli"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	PhotoThumbnails map[int]string `gorm:"type:jsonb;serializer:json"`
+	// PhotoBlob is hash of stored photo, it keeps the blob from GC
+	PhotoBlob *string `gorm:"index"`
+
+	ExpandSensitiveMedia bool `gorm:"not null;default:false"`
 }
 
 type UserRepository struct {
@@ -36,6 +42,12 @@ func (m *User) toDomain() *user.User {
 		PhotoURL:  m.PhotoURL,
 		CreatedAt: m.CreatedAt,
 		DeletedAt: m.DeletedAt,
+
+		PhotoThumbnails: m.PhotoThumbnails,
+
+		Preferences: user.Preferences{
+			ExpandSensitiveMedia: m.ExpandSensitiveMedia,
+		},
 	}
 }
 func toDomainUsers(models []User) []user.User {
@@ -57,6 +69,10 @@ func fromDomain(u *user.User) *User {
 		PhotoURL:  u.PhotoURL,
 		CreatedAt: u.CreatedAt,
 		DeletedAt: u.DeletedAt,
+
+		PhotoThumbnails: u.PhotoThumbnails,
+
+		ExpandSensitiveMedia: u.Preferences.ExpandSensitiveMedia,
 	}
 }
 
@@ -84,7 +100,7 @@ func (r *UserRepository) Create(user *user.User) error {
 }
 
 func (r *UserRepository) Delete(id string) error {
-	return r.db.Where("id = ?", id).Delete(&user.User{}).Error
+	return r.db.Where("id = ?", id).Delete(&User{}).Error
 }
 
 func (r *UserRepository) GetUsers() ([]user.User, error) {
@@ -134,12 +150,24 @@ func (r *UserRepository) Search(username string) ([]user.User, error) {
 	return toDomainUsers(models), err
 }
 
-func (r *UserRepository) UpdatePhoto(username, photo_url string) error {
+// UpdatePhoto sets user photo, blob is empty for photos which are not
+// stored by us. Previous blob is released and collected by GC later
+func (r *UserRepository) UpdatePhoto(username, photo_url string, thumbnails map[int]string, blob string) error {
 	if photo_url == "" {
 		return errors.New("photo_url is empty")
 	}
 
-	return r.db.Model(&User{}).Where("username = ?", username).Update("photo_url", photo_url).Error
+	return r.db.Model(&User{}).Where("username = ?", username).Select("photo_url", "photo_thumbnails", "photo_blob").Updates(&User{
+		PhotoURL:        photo_url,
+		PhotoThumbnails: thumbnails,
+		PhotoBlob:       nullableString(blob),
+	}).Error
+}
+
+func (r *UserRepository) UpdatePreferences(id string, prefs user.Preferences) error {
+	return r.db.Model(&User{}).Where("id = ? AND deleted_at IS NULL", id).Updates(map[string]interface{}{
+		"expand_sensitive_media": prefs.ExpandSensitiveMedia,
+	}).Error
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+)
+
+// PostViewModel remembers who viewed a post within a window, so repeated
+// views are not counted again
+type PostViewModel struct {
+	PostID   string    `gorm:"primaryKey;not null"`
+	ViewerID string    `gorm:"primaryKey;not null"`
+	Bucket   time.Time `gorm:"primaryKey;not null;index"`
+}
+
+// AddViews inserts views and increments counters by views which were not
+// there yet, in one statement, and returns number of new views
+func (r *PostRepository) AddViews(ctx context.Context, views []post.View) (int64, error) {
+	if len(views) == 0 {
+		return 0, nil
+	}
+
+	placeholders := make([]string, len(views))
+	args := make([]interface{}, 0, 3*len(views))
+	for i, v := range views {
+		placeholders[i] = "(?, ?, ?)"
+		args = append(args, v.PostID, v.ViewerID, v.Bucket)
+	}
+
+	var added int64
+	err := r.db.WithContext(ctx).Raw(`
+		WITH added AS (
+			INSERT INTO post_view_models (post_id, viewer_id, bucket) VALUES `+strings.Join(placeholders, ", ")+`
+			ON CONFLICT DO NOTHING
+			RETURNING post_id
+		), counted AS (
+			UPDATE post_models SET views_count = post_models.views_count + counts.n
+			FROM (SELECT post_id, COUNT(*) AS n FROM added GROUP BY post_id) AS counts
+			WHERE post_models.id = counts.post_id
+			RETURNING counts.n
+		)
+		SELECT COALESCE(SUM(n), 0) FROM counted`, args...).
+		Scan(&added).Error
+
+	return added, err
+}
+
+func (r *PostRepository) PruneViews(ctx context.Context, bucket time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("bucket < ?", bucket).
+		Delete(&PostViewModel{}).Error
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/critiq17/critiqal-site/internal/domain/bookmark"
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+)
+
+type BookmarkService struct {
+	bookmarkRepo bookmark.Repository
+	postRepo     post.Repository
+}
+
+func NewBookmarkService(bookmarkRepo bookmark.Repository, postRepo post.Repository) *BookmarkService {
+	return &BookmarkService{
+		bookmarkRepo: bookmarkRepo,
+		postRepo:     postRepo,
+	}
+}
+
+// Save bookmarks post visible to userID. Saving bookmarked post again
+// moves it to collectionID, nil moves it to the default list
+func (s *BookmarkService) Save(ctx context.Context, userID, postID string, collectionID *string) error {
+	if _, err := s.postRepo.Get(ctx, userID, postID); err != nil {
+		if errors.Is(err, post.ErrNotFound) {
+			return bookmark.ErrPostNotFound
+		}
+		return err
+	}
+
+	if collectionID != nil {
+		if _, err := s.bookmarkRepo.GetCollection(ctx, userID, *collectionID); err != nil {
+			return err
+		}
+	}
+
+	return s.bookmarkRepo.Save(ctx, userID, postID, collectionID)
+}
+
+func (s *BookmarkService) Remove(ctx context.Context, userID, postID string) error {
+	return s.bookmarkRepo.Remove(ctx, userID, postID)
+}
+
+// CheckCollection verifies collection belongs to userID
+func (s *BookmarkService) CheckCollection(ctx context.Context, userID, id string) error {
+	_, err := s.bookmarkRepo.GetCollection(ctx, userID, id)
+	return err
+}
+
+func (s *BookmarkService) CreateCollection(ctx context.Context, userID, name string) (*bookmark.Collection, error) {
+	name, err := s.validateCollectionName(ctx, userID, "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &bookmark.Collection{OwnerID: userID, Name: name}
+	if err := s.bookmarkRepo.CreateCollection(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *BookmarkService) ListCollections(ctx context.Context, userID string) ([]*bookmark.Collection, error) {
+	return s.bookmarkRepo.ListCollections(ctx, userID)
+}
+
+func (s *BookmarkService) RenameCollection(ctx context.Context, userID, id, name string) (*bookmark.Collection, error) {
+	if _, err := s.bookmarkRepo.GetCollection(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	name, err := s.validateCollectionName(ctx, userID, id, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.bookmarkRepo.RenameCollection(ctx, userID, id, name); err != nil {
+		return nil, err
+	}
+
+	return s.bookmarkRepo.GetCollection(ctx, userID, id)
+}
+
+// DeleteCollection removes collection, its bookmarks are kept
+func (s *BookmarkService) DeleteCollection(ctx context.Context, userID, id string) error {
+	if _, err := s.bookmarkRepo.GetCollection(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return s.bookmarkRepo.DeleteCollection(ctx, userID, id)
+}
+
+// validateCollectionName trims name and checks no other collection of
+// userID than id uses it
+func (s *BookmarkService) validateCollectionName(ctx context.Context, userID, id, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > bookmark.MaxCollectionNameLength {
+		return "", bookmark.ErrInvalidName
+	}
+
+	existing, err := s.bookmarkRepo.FindCollectionByName(ctx, userID, name)
+	switch {
+	case err == nil && existing.ID != id:
+		return "", bookmark.ErrCollectionExists
+	case err != nil && !errors.Is(err, bookmark.ErrCollectionNotFound):
+		return "", err
+	}
+
+	return name, nil
+}
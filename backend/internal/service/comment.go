@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/critiq17/critiqal-site/internal/domain/comment"
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+)
+
+type CommentService struct {
+	commentRepo   comment.Repository
+	postRepo      post.Repository
+	notifications *NotificationService
+	events        *Events
+}
+
+func NewCommentService(commentRepo comment.Repository, postRepo post.Repository, notifications *NotificationService, events *Events) *CommentService {
+	return &CommentService{
+		commentRepo:   commentRepo,
+		postRepo:      postRepo,
+		notifications: notifications,
+		events:        events,
+	}
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", comment.ErrEmptyBody
+	}
+	if utf8.RuneCountInString(body) > comment.MaxBodyLength {
+		return "", comment.ErrBodyTooLong
+	}
+	return body, nil
+}
+
+// Create adds a comment to a post. If ParentID is set the comment becomes
+// a reply in the parent's thread
+func (s *CommentService) Create(ctx context.Context, c *comment.Comment) error {
+	body, err := validateCommentBody(c.Body)
+	if err != nil {
+		return err
+	}
+	c.Body = body
+
+	p, err := s.postRepo.Get(ctx, c.AuthorID, c.PostID)
+	if err != nil {
+		return comment.ErrPostNotFound
+	}
+	// owner of the post and author of the replied comment are notified
+	recipients := []string{p.OwnerID}
+
+	c.Depth = 0
+	c.RootID = nil
+
+	if c.ParentID != nil {
+		parent, err := s.commentRepo.Get(ctx, *c.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.PostID != c.PostID {
+			return comment.ErrWrongPost
+		}
+		if parent.IsDeleted() {
+			return comment.ErrDeleted
+		}
+		if parent.Depth+1 > comment.MaxDepth {
+			return comment.ErrMaxDepth
+		}
+
+		c.Depth = parent.Depth + 1
+		c.RootID = parent.RootID
+		if c.RootID == nil {
+			c.RootID = &parent.ID
+		}
+		if parent.AuthorID != p.OwnerID {
+			recipients = append(recipients, parent.AuthorID)
+		}
+	}
+
+	if err := s.commentRepo.Create(ctx, c); err != nil {
+		return err
+	}
+
+	created, err := s.commentRepo.Get(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	*c = *created
+
+	s.events.CountsChanged(p.ID, p.OwnerID)
+	for _, recipientID := range recipients {
+		s.notifications.Notify(ctx, notification.Event{
+			Type:        notification.TypeComment,
+			RecipientID: recipientID,
+			ActorID:     c.AuthorID,
+			PostID:      &c.PostID,
+		})
+	}
+	return nil
+}
+
+// Update changes the body of a comment, only its author can edit it
+func (s *CommentService) Update(ctx context.Context, userID, id, body string) (*comment.Comment, error) {
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.commentRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.IsDeleted() {
+		return nil, comment.ErrDeleted
+	}
+	if existing.AuthorID != userID {
+		return nil, comment.ErrForbidden
+	}
+
+	if err := s.commentRepo.Update(ctx, id, body); err != nil {
+		return nil, err
+	}
+
+	return s.commentRepo.Get(ctx, id)
+}
+
+// Delete soft deletes a comment. Allowed for the comment author and
+// the owner of the post it was left on
+func (s *CommentService) Delete(ctx context.Context, userID, id string) error {
+	existing, err := s.commentRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.IsDeleted() {
+		return nil
+	}
+
+	p, err := s.postRepo.Get(ctx, userID, existing.PostID)
+	if existing.AuthorID != userID && (err != nil || p.OwnerID != userID) {
+		return comment.ErrForbidden
+	}
+
+	if err := s.commentRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if p != nil {
+		s.events.CountsChanged(p.ID, p.OwnerID)
+	}
+	return nil
+}
+
+// List retrieves a page of threads of a post visible to viewerID with
+// nested replies and returns cursor of the next page (empty when there
+// are no more threads). Threads list up to comment.MaxThreadReplies
+// replies, the rest are loaded by ListReplies
+func (s *CommentService) List(ctx context.Context, viewerID, postID, after string, limit int) ([]*comment.Comment, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	if _, err := s.postRepo.Get(ctx, viewerID, postID); err != nil {
+		return nil, "", comment.ErrPostNotFound
+	}
+
+	roots, err := s.commentRepo.ListRoots(ctx, postID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rootIDs := make([]string, len(roots))
+	for i, r := range roots {
+		rootIDs[i] = r.ID
+	}
+
+	// one extra reply tells whether a thread continues
+	replies, err := s.commentRepo.ListReplies(ctx, rootIDs, comment.MaxThreadReplies+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	replies = cutThreads(roots, replies)
+	buildCommentTree(roots, replies)
+
+	next := ""
+	if len(roots) == limit {
+		last := roots[len(roots)-1]
+		next = cursor.Encode(*last.CreatedAt, last.ID)
+	}
+
+	return roots, next, nil
+}
+
+// ListReplies retrieves a page of replies of thread rootID, oldest first,
+// and cursor of the next page. It continues RepliesCursor of the thread,
+// replies are flat and are attached to their parents by ParentID
+func (s *CommentService) ListReplies(ctx context.Context, viewerID, postID, rootID, after string, limit int) ([]*comment.Comment, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	if _, err := s.postRepo.Get(ctx, viewerID, postID); err != nil {
+		return nil, "", comment.ErrPostNotFound
+	}
+
+	root, err := s.commentRepo.Get(ctx, rootID)
+	if err != nil {
+		return nil, "", err
+	}
+	if root.PostID != postID || root.ParentID != nil {
+		return nil, "", comment.ErrNotFound
+	}
+
+	replies, err := s.commentRepo.ListThread(ctx, rootID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(replies) == limit {
+		last := replies[len(replies)-1]
+		next = cursor.Encode(*last.CreatedAt, last.ID)
+	}
+
+	return replies, next, nil
+}
+
+// cutThreads keeps up to comment.MaxThreadReplies replies of every thread
+// and sets RepliesCursor of threads which have more
+func cutThreads(roots, replies []*comment.Comment) []*comment.Comment {
+	counts := make(map[string]int, len(roots))
+	last := make(map[string]*comment.Comment, len(roots))
+
+	kept := replies[:0]
+	for _, c := range replies {
+		if c.RootID == nil {
+			continue
+		}
+		rootID := *c.RootID
+		counts[rootID]++
+		if counts[rootID] > comment.MaxThreadReplies {
+			continue
+		}
+		last[rootID] = c
+		kept = append(kept, c)
+	}
+
+	for _, r := range roots {
+		if counts[r.ID] > comment.MaxThreadReplies {
+			c := last[r.ID]
+			r.RepliesCursor = cursor.Encode(*c.CreatedAt, c.ID)
+		}
+	}
+	return kept
+}
+
+// buildCommentTree attaches replies to their parents and drops deleted
+// replies which have nothing left under them
+func buildCommentTree(roots, replies []*comment.Comment) {
+	byID := make(map[string]*comment.Comment, len(roots)+len(replies))
+	for _, c := range roots {
+		byID[c.ID] = c
+	}
+	for _, c := range replies {
+		byID[c.ID] = c
+	}
+
+	// replies are sorted by creation time so parents always come first
+	for _, c := range replies {
+		if c.ParentID == nil {
+			continue
+		}
+		if parent, ok := byID[*c.ParentID]; ok {
+			parent.Replies = append(parent.Replies, c)
+		}
+	}
+
+	for _, r := range roots {
+		r.Replies = pruneDeleted(r.Replies)
+	}
+}
+
+func pruneDeleted(comments []*comment.Comment) []*comment.Comment {
+	out := comments[:0]
+	for _, c := range comments {
+		c.Replies = pruneDeleted(c.Replies)
+		if c.IsDeleted() && len(c.Replies) == 0 {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+	"github.com/critiq17/critiqal-site/pkg/pubsub"
+)
+
+// topicFeed carries events about posts of all users, every stream picks
+// the ones its user follows
+const topicFeed = "feed"
+
+// userTopic carries events meant for a single user
+func userTopic(userID string) string {
+	return "user:" + userID
+}
+
+// Types of real-time events
+const (
+	EventPost         = "post"
+	EventCounts       = "counts"
+	EventNotification = "notification"
+	EventMessage      = "message"
+	// EventRead tells other members of a conversation or group it was read
+	EventRead = "read"
+	// eventFollows tells streams of a user to reload followees, it is not sent to clients
+	eventFollows = "follows"
+)
+
+// Event is published to streams. It carries only IDs, payloads of
+// NOTIFY are limited and every stream loads posts as its user sees them
+type Event struct {
+	Type             string `json:"type"`
+	PostID           string `json:"post_id,omitempty"`
+	OwnerID          string `json:"owner_id,omitempty"`
+	NotificationType string `json:"notification_type,omitempty"`
+	ConversationID   string `json:"conversation_id,omitempty"`
+	MessageID        string `json:"message_id,omitempty"`
+}
+
+// Events publishes real-time events. Failures are only logged, clients
+// catch up by reloading
+type Events struct {
+	ps  pubsub.PubSub
+	log *logger.Logger
+}
+
+func NewEvents(ps pubsub.PubSub, log *logger.Logger) *Events {
+	return &Events{
+		ps:  ps,
+		log: log,
+	}
+}
+
+func (e *Events) publish(topic string, ev Event) {
+	data, err := json.Marshal(ev)
+	if err == nil {
+		err = e.ps.Publish(context.Background(), topic, data)
+	}
+	if err != nil {
+		e.log.Error("publishing event failed", err.Error())
+	}
+}
+
+// PostPublished announces p to feeds of followers of its owner
+func (e *Events) PostPublished(p *post.Post) {
+	e.publish(topicFeed, Event{Type: EventPost, PostID: p.ID, OwnerID: p.OwnerID})
+}
+
+// CountsChanged announces changed comments or reposts count of post
+func (e *Events) CountsChanged(postID, ownerID string) {
+	e.publish(topicFeed, Event{Type: EventCounts, PostID: postID, OwnerID: ownerID})
+}
+
+func (e *Events) Notified(n *notification.Event) {
+	ev := Event{Type: EventNotification, NotificationType: string(n.Type)}
+	if n.PostID != nil {
+		ev.PostID = *n.PostID
+	}
+	e.publish(userTopic(n.RecipientID), ev)
+}
+
+// MessageSent announces new message to a member of its conversation or group
+func (e *Events) MessageSent(recipientID, conversationID, messageID string) {
+	e.publish(userTopic(recipientID), Event{Type: EventMessage, ConversationID: conversationID, MessageID: messageID})
+}
+
+func (e *Events) ConversationRead(recipientID, conversationID string) {
+	e.publish(userTopic(recipientID), Event{Type: EventRead, ConversationID: conversationID})
+}
+
+func (e *Events) FollowsChanged(userID string) {
+	e.publish(userTopic(userID), Event{Type: eventFollows})
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/storage"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+)
+
+// gcBatchSize limits blobs deleted in one transaction
+const gcBatchSize = 100
+
+// MediaGC periodically deletes media which were never attached to a post
+// or message and blobs nothing references anymore. Grace period protects
+// uploads which are about to be attached and blobs reused right before
+// collection
+type MediaGC struct {
+	repo     media.Repository
+	storage  storage.Storage
+	log      *logger.Logger
+	interval time.Duration
+	grace    time.Duration
+}
+
+func NewMediaGC(repo media.Repository, storage storage.Storage, log *logger.Logger, interval, grace time.Duration) *MediaGC {
+	return &MediaGC{
+		repo: repo, storage: storage, log: log, interval: interval, grace: grace,
+	}
+}
+
+// Run collects garbage every interval until ctx is done
+func (gc *MediaGC) Run(ctx context.Context) {
+	ticker := time.NewTicker(gc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := gc.Collect(ctx, time.Now()); err != nil {
+				gc.log.Error("media gc failed", err.Error())
+			} else if n > 0 {
+				gc.log.Info("media gc deleted blobs", n)
+			}
+		}
+	}
+}
+
+// Collect deletes garbage older than grace period at now and returns
+// number of deleted blobs
+func (gc *MediaGC) Collect(ctx context.Context, now time.Time) (int, error) {
+	before := now.Add(-gc.grace)
+
+	if _, err := gc.repo.DeleteUnattached(ctx, before); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		n, err := gc.repo.DeleteOrphanBlobs(ctx, before, gcBatchSize, gc.deleteContent(ctx))
+		total += n
+		if err != nil || n < gcBatchSize {
+			return total, err
+		}
+	}
+}
+
+func (gc *MediaGC) deleteContent(ctx context.Context) func(*media.Blob) error {
+	return func(b *media.Blob) error {
+		paths := []string{b.Path}
+		for _, t := range b.Thumbnails {
+			paths = append(paths, t.Path)
+		}
+
+		for _, p := range paths {
+			// content may be partially deleted by a previous failed run
+			if err := gc.storage.DeleteFile(ctx, p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/critiq17/critiqal-site/internal/domain/group"
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+	"github.com/critiq17/critiqal-site/internal/storage"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+)
+
+type GroupService struct {
+	groupRepo   group.Repository
+	messageRepo message.Repository
+	userRepo    user.Repository
+	mediaRepo   media.Repository
+	storage     storage.Storage
+	events      *Events
+	maxMembers  int
+}
+
+func NewGroupService(groupRepo group.Repository, messageRepo message.Repository, userRepo user.Repository, mediaRepo media.Repository, storage storage.Storage, events *Events, maxMembers int) *GroupService {
+	return &GroupService{
+		groupRepo:   groupRepo,
+		messageRepo: messageRepo,
+		userRepo:    userRepo,
+		mediaRepo:   mediaRepo,
+		storage:     storage,
+		events:      events,
+		maxMembers:  maxMembers,
+	}
+}
+
+// sign replaces media URLs of last messages of groups by signed ones
+func (s *GroupService) sign(ctx context.Context, list ...*group.Group) error {
+	for _, g := range list {
+		if g.LastMessage == nil {
+			continue
+		}
+		if err := signMessages(ctx, s.storage, g.LastMessage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > group.MaxTitleLength {
+		return "", group.ErrInvalidTitle
+	}
+	return title, nil
+}
+
+// resolveUsers looks up users of usernames to join memberIDs, leaving out
+// duplicates and members. message.ErrBlocked is returned when a block
+// exists between any two users who would end up in the group
+func (s *GroupService) resolveUsers(memberIDs []string, usernames []string) ([]string, error) {
+	seen := make(map[string]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		seen[id] = true
+	}
+
+	ids := []string{}
+	for _, username := range usernames {
+		u, err := s.userRepo.GetUserByUsername(username)
+		if err != nil {
+			return nil, group.ErrUserNotFound
+		}
+		if !seen[u.ID] {
+			seen[u.ID] = true
+			ids = append(ids, u.ID)
+		}
+	}
+
+	// new users are checked against members and against each other
+	blocked, err := s.userRepo.AnyBlocked(ids, slices.Concat(ids, memberIDs))
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, message.ErrBlocked
+	}
+	return ids, nil
+}
+
+// getAdmin retrieves group userID administers
+func (s *GroupService) getAdmin(ctx context.Context, userID, id string) (*group.Group, error) {
+	g, err := s.groupRepo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !g.IsAdmin(userID) {
+		return nil, group.ErrForbidden
+	}
+	return g, nil
+}
+
+// announce tells members of g except userID about its last message
+func (s *GroupService) announce(g *group.Group, userID string) {
+	if g.LastMessage == nil {
+		return
+	}
+	for _, member := range g.Members {
+		if member.UserID != userID {
+			s.events.MessageSent(member.UserID, g.ID, g.LastMessage.ID)
+		}
+	}
+}
+
+// Create starts group of userID as its admin with users of usernames
+func (s *GroupService) Create(ctx context.Context, userID, title string, usernames []string) (*group.Group, error) {
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := s.resolveUsers([]string{userID}, usernames)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs)+1 > s.maxMembers {
+		return nil, group.ErrMemberLimit
+	}
+
+	g := &group.Group{Title: title}
+	if err := s.groupRepo.Create(ctx, g, userID, memberIDs); err != nil {
+		return nil, err
+	}
+
+	g, err = s.Get(ctx, userID, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.announce(g, userID)
+	return g, nil
+}
+
+func (s *GroupService) Get(ctx context.Context, userID, id string) (*group.Group, error) {
+	g, err := s.groupRepo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sign(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// List retrieves a page of groups of userID, latest activity first, and
+// cursor of the next page
+func (s *GroupService) List(ctx context.Context, userID, after string, limit int) ([]*group.Group, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	list, err := s.groupRepo.List(ctx, userID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.sign(ctx, list...); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(list) == limit {
+		last := list[len(list)-1]
+		next = cursor.Encode(last.LastMessageAt, last.ID)
+	}
+
+	return list, next, nil
+}
+
+func (s *GroupService) Rename(ctx context.Context, userID, id, title string) (*group.Group, error) {
+	if _, err := s.getAdmin(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	title, err := validateTitle(title)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.groupRepo.Rename(ctx, id, userID, title); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, userID, id)
+}
+
+// AddMembers adds users of usernames to group userID administers, users
+// who are already members are skipped
+func (s *GroupService) AddMembers(ctx context.Context, userID, id string, usernames []string) (*group.Group, error) {
+	g, err := s.getAdmin(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs := make([]string, len(g.Members))
+	for i, m := range g.Members {
+		memberIDs[i] = m.UserID
+	}
+	ids, err := s.resolveUsers(memberIDs, usernames)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.groupRepo.AddMembers(ctx, id, userID, ids, s.maxMembers); err != nil {
+		return nil, err
+	}
+
+	g, err = s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	s.announce(g, userID)
+	return g, nil
+}
+
+// RemoveMember removes user of username from group userID administers,
+// any member can remove themselves
+func (s *GroupService) RemoveMember(ctx context.Context, userID, id, username string) error {
+	u, err := s.userRepo.GetUserByUsername(username)
+	if err != nil {
+		return group.ErrUserNotFound
+	}
+	if u.ID == userID {
+		return s.Leave(ctx, userID, id)
+	}
+
+	g, err := s.getAdmin(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.removeMember(ctx, g, userID, u.ID)
+}
+
+// Leave removes userID from group, an admin is promoted if they were the last one
+func (s *GroupService) Leave(ctx context.Context, userID, id string) error {
+	g, err := s.groupRepo.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return s.removeMember(ctx, g, userID, userID)
+}
+
+// removeMember removes userID from g and announces the system message to
+// members of g, including the removed one
+func (s *GroupService) removeMember(ctx context.Context, g *group.Group, actorID, userID string) error {
+	if err := s.groupRepo.RemoveMember(ctx, g.ID, actorID, userID); err != nil {
+		return err
+	}
+
+	latest, err := s.messageRepo.GetMessages(ctx, g.ID, nil, "", 1)
+	if err != nil || len(latest) == 0 {
+		return err
+	}
+	g.LastMessage = latest[0]
+	s.announce(g, actorID)
+	return nil
+}
+
+// SetRole promotes or demotes member of username in group userID administers
+func (s *GroupService) SetRole(ctx context.Context, userID, id, username string, role group.Role) (*group.Group, error) {
+	if role != group.RoleAdmin && role != group.RoleMember {
+		return nil, group.ErrInvalidRole
+	}
+	if _, err := s.getAdmin(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	u, err := s.userRepo.GetUserByUsername(username)
+	if err != nil {
+		return nil, group.ErrUserNotFound
+	}
+	if err := s.groupRepo.SetRole(ctx, id, userID, u.ID, role); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, userID, id)
+}
+
+// Send adds message of userID to group they are a member of and returns the group
+func (s *GroupService) Send(ctx context.Context, userID, id string, m *message.Message) (*group.Group, error) {
+	g, err := s.groupRepo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateMessage(ctx, s.mediaRepo, userID, m); err != nil {
+		return nil, err
+	}
+
+	m.ConversationID = g.ID
+	m.SenderID = userID
+	if err := s.messageRepo.CreateMessage(ctx, m); err != nil {
+		return nil, err
+	}
+	if err := signMessages(ctx, s.storage, m); err != nil {
+		return nil, err
+	}
+
+	g.LastMessage = m
+	s.announce(g, userID)
+	return g, nil
+}
+
+// GetMessages retrieves a page of messages of group userID is a member
+// of, newest first, with the group and cursor of the next page
+func (s *GroupService) GetMessages(ctx context.Context, userID, id, after string, limit int) (*group.Group, []*message.Message, string, error) {
+	g, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	// history before the member joined stays hidden
+	messages, err := s.messageRepo.GetMessages(ctx, id, g.Member(userID).JoinedAt, after, limit)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err := signMessages(ctx, s.storage, messages...); err != nil {
+		return nil, nil, "", err
+	}
+
+	next := ""
+	if len(messages) == limit {
+		last := messages[len(messages)-1]
+		next = cursor.Encode(*last.CreatedAt, last.ID)
+	}
+
+	return g, messages, next, nil
+}
+
+// MarkRead reads group up to its latest message, other members are told
+// so they can update read receipts
+func (s *GroupService) MarkRead(ctx context.Context, userID, id string) error {
+	g, err := s.groupRepo.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.messageRepo.MarkRead(ctx, userID, id); err != nil {
+		return err
+	}
+
+	for _, member := range g.Members {
+		if member.UserID != userID {
+			s.events.ConversationRead(member.UserID, g.ID)
+		}
+	}
+	return nil
+}
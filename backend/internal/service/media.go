@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/storage"
+	"github.com/critiq17/critiqal-site/pkg/imaging"
+	"github.com/google/uuid"
+)
+
+// privateMediaTTL is how long signed URLs of private media stay valid,
+// clients reload messages to get fresh ones
+const privateMediaTTL = time.Hour
+
+type MediaService struct {
+	repo    media.Repository
+	storage storage.Storage
+}
+
+func NewMediaService(repo media.Repository, storage storage.Storage) *MediaService {
+	return &MediaService{
+		repo: repo, storage: storage,
+	}
+}
+
+// Upload stores post image of ownerID. File name from the client is
+// ignored, content is stored under its hash and shared by identical uploads
+func (s *MediaService) Upload(ctx context.Context, ownerID string, file *multipart.FileHeader, altText string) (*media.Media, error) {
+	return s.upload(ctx, ownerID, file, altText, false)
+}
+
+// UploadPrivate stores message image of ownerID under
+// storage.PrivatePrefix. Returned media carries signed URLs
+func (s *MediaService) UploadPrivate(ctx context.Context, ownerID string, file *multipart.FileHeader, altText string) (*media.Media, error) {
+	m, err := s.upload(ctx, ownerID, file, altText, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := signMedia(ctx, s.storage, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *MediaService) upload(ctx context.Context, ownerID string, file *multipart.FileHeader, altText string, private bool) (*media.Media, error) {
+	if s.storage == nil {
+		return nil, media.ErrStorageUnavailable
+	}
+
+	altText = strings.TrimSpace(altText)
+	if utf8.RuneCountInString(altText) > media.MaxAltTextLength {
+		return nil, media.ErrAltTextTooLong
+	}
+
+	res, err := processUpload(file)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &media.Media{
+		ID:          uuid.NewString(),
+		OwnerID:     ownerID,
+		ContentType: res.Original.ContentType,
+		Size:        int64(len(res.Original.Data)),
+		Width:       res.Original.Width,
+		Height:      res.Original.Height,
+		AltText:     altText,
+		Private:     private,
+	}
+
+	blob, err := storeBlob(ctx, s.repo, s.storage, res, private)
+	if err != nil {
+		return nil, err
+	}
+	m.BlobHash = blob.Hash
+	m.Path = blob.Path
+	m.URL = blob.URL
+	m.Thumbnails = blob.Thumbnails
+
+	// if saving fails the blob stays unreferenced and is collected by GC
+	if err := s.repo.Create(ctx, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// SignedURL issues expiring URL of a stored file, it is used for media
+// which must not be accessible by its public URL
+func (s *MediaService) SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	if s.storage == nil {
+		return "", media.ErrStorageUnavailable
+	}
+	return s.storage.SignedURL(ctx, path, ttl)
+}
+
+// ResolveSigned verifies signed URL issued by local storage and returns
+// the file to serve
+func (s *MediaService) ResolveSigned(path string, expires int64, signature string) (string, error) {
+	v, ok := s.storage.(storage.SignatureVerifier)
+	if !ok {
+		return "", media.ErrNotFound
+	}
+	return v.VerifySigned(path, expires, signature)
+}
+
+// signMedia replaces URLs of private media and their thumbnails by
+// signed ones, public media are left as they are
+func signMedia(ctx context.Context, st storage.Storage, list ...*media.Media) error {
+	for _, m := range list {
+		if !m.Private {
+			continue
+		}
+
+		url, err := st.SignedURL(ctx, m.Path, privateMediaTTL)
+		if err != nil {
+			return err
+		}
+		m.URL = url
+
+		// thumbnails are shared with the blob, they are copied before
+		// their URLs are replaced
+		thumbnails := make([]media.Thumbnail, len(m.Thumbnails))
+		for i, t := range m.Thumbnails {
+			t.URL, err = st.SignedURL(ctx, t.Path, privateMediaTTL)
+			if err != nil {
+				return err
+			}
+			thumbnails[i] = t
+		}
+		m.Thumbnails = thumbnails
+	}
+	return nil
+}
+
+// processUpload runs uploaded file through imaging pipeline and maps its
+// errors to media errors
+func processUpload(file *multipart.FileHeader) (*imaging.Result, error) {
+	if file.Size > media.MaxUploadSize {
+		return nil, media.ErrTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer src.Close()
+
+	opts := imaging.DefaultOptions
+	opts.MaxBytes = media.MaxUploadSize
+
+	res, err := imaging.Process(src, opts)
+	switch {
+	case errors.Is(err, imaging.ErrTooLarge), errors.Is(err, imaging.ErrTooManyPixels):
+		return nil, media.ErrTooLarge
+	case errors.Is(err, imaging.ErrUnsupportedType), errors.Is(err, imaging.ErrInvalid):
+		return nil, media.ErrUnsupportedType
+	case err != nil:
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// storeBlob stores processed image under media/<hash[:2]>/<hash>, hash
+// is SHA-256 of the processed original. Content which is already stored
+// is not uploaded again
+func storeBlob(ctx context.Context, repo media.Repository, st storage.Storage, res *imaging.Result, private bool) (*media.Blob, error) {
+	sum := sha256.Sum256(res.Original.Data)
+	hash := hex.EncodeToString(sum[:])
+	base := fmt.Sprintf("media/%s/%s", hash[:2], hash)
+	if private {
+		// private blobs are keyed apart from public ones, so identical
+		// content uploaded to a post never gets a private blob's path
+		hash = storage.PrivatePrefix + hash
+		base = storage.PrivatePrefix + base
+	}
+
+	b := &media.Blob{
+		Hash:        hash,
+		Path:        base + res.Original.Ext,
+		ContentType: res.Original.ContentType,
+		Size:        int64(len(res.Original.Data)),
+		Width:       res.Original.Width,
+		Height:      res.Original.Height,
+	}
+
+	err := repo.AcquireBlob(ctx, b, func(b *media.Blob) error {
+		stored, err := storeImage(ctx, st, base, res)
+		if err != nil {
+			return err
+		}
+		b.URL = stored.URL
+		b.Thumbnails = stored.Thumbnails
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// makePrivate copies content of public media m to a private blob and
+// points m to it. The public blob is collected by GC once unreferenced
+func makePrivate(ctx context.Context, repo media.Repository, st storage.Storage, m *media.Media) error {
+	if m.Private {
+		return nil
+	}
+
+	// media uploaded before blobs existed are keyed by their path
+	key := m.BlobHash
+	if key == "" {
+		key = m.Path
+	}
+
+	b := &media.Blob{
+		Hash:        storage.PrivatePrefix + key,
+		Path:        storage.PrivatePrefix + m.Path,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+	}
+
+	err := repo.AcquireBlob(ctx, b, func(b *media.Blob) error {
+		stored := &storedImage{Path: b.Path}
+
+		url, err := st.Copy(ctx, m.Path, stored.Path)
+		if err != nil {
+			return err
+		}
+		stored.URL = url
+
+		for _, t := range m.Thumbnails {
+			path := storage.PrivatePrefix + t.Path
+			url, err := st.Copy(ctx, t.Path, path)
+			if err != nil {
+				stored.delete(ctx, st)
+				return err
+			}
+			stored.Thumbnails = append(stored.Thumbnails, media.Thumbnail{Size: t.Size, Path: path, URL: url})
+		}
+
+		b.URL = stored.URL
+		b.Thumbnails = stored.Thumbnails
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := repo.MakePrivate(ctx, m.ID, b); err != nil {
+		return err
+	}
+	m.Private = true
+	m.BlobHash = b.Hash
+	m.Path = b.Path
+	m.URL = b.URL
+	m.Thumbnails = b.Thumbnails
+	return nil
+}
+
+// storedImage is where processed image and its thumbnails were put
+type storedImage struct {
+	Path       string
+	URL        string
+	Thumbnails []media.Thumbnail
+}
+
+// storeImage puts processed image to base+ext and thumbnails to
+// base_<size>+ext
+func storeImage(ctx context.Context, st storage.Storage, base string, res *imaging.Result) (*storedImage, error) {
+	stored := &storedImage{Path: base + res.Original.Ext}
+
+	url, err := st.Put(ctx, stored.Path, bytes.NewReader(res.Original.Data), res.Original.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	stored.URL = url
+
+	for _, size := range imaging.ThumbnailSizes {
+		t := res.Thumbnails[size]
+		path := fmt.Sprintf("%s_%d%s", base, size, t.Ext)
+
+		url, err := st.Put(ctx, path, bytes.NewReader(t.Data), t.ContentType)
+		if err != nil {
+			stored.delete(ctx, st)
+			return nil, err
+		}
+
+		stored.Thumbnails = append(stored.Thumbnails, media.Thumbnail{Size: size, Path: path, URL: url})
+	}
+
+	return stored, nil
+}
+
+func (si *storedImage) delete(ctx context.Context, st storage.Storage) {
+	_ = st.DeleteFile(ctx, si.Path)
+	for _, t := range si.Thumbnails {
+		_ = st.DeleteFile(ctx, t.Path)
+	}
+}
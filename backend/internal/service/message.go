@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/message"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+	"github.com/critiq17/critiqal-site/internal/storage"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+)
+
+type MessageService struct {
+	messageRepo message.Repository
+	userRepo    user.Repository
+	mediaRepo   media.Repository
+	storage     storage.Storage
+	events      *Events
+}
+
+func NewMessageService(messageRepo message.Repository, userRepo user.Repository, mediaRepo media.Repository, storage storage.Storage, events *Events) *MessageService {
+	return &MessageService{
+		messageRepo: messageRepo,
+		userRepo:    userRepo,
+		mediaRepo:   mediaRepo,
+		storage:     storage,
+		events:      events,
+	}
+}
+
+// Open retrieves conversation of userID with user of username, starting
+// it if they haven't talked yet. Users who block each other can't talk
+func (s *MessageService) Open(ctx context.Context, userID, username string) (*message.Conversation, error) {
+	other, err := s.userRepo.GetUserByUsername(username)
+	if err != nil {
+		return nil, message.ErrUserNotFound
+	}
+	if other.ID == userID {
+		return nil, message.ErrSelfConversation
+	}
+	if err := s.checkBlocked(userID, other.ID); err != nil {
+		return nil, err
+	}
+
+	conv, err := s.messageRepo.GetOrCreateDirect(ctx, userID, other.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sign(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+func (s *MessageService) Get(ctx context.Context, userID, id string) (*message.Conversation, error) {
+	conv, err := s.messageRepo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sign(ctx, conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// List retrieves a page of conversations of userID, latest message first,
+// and cursor of the next page
+func (s *MessageService) List(ctx context.Context, userID, after string, limit int) ([]*message.Conversation, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	list, err := s.messageRepo.List(ctx, userID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.sign(ctx, list...); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(list) == limit {
+		last := list[len(list)-1]
+		next = cursor.Encode(last.LastMessageAt, last.ID)
+	}
+
+	return list, next, nil
+}
+
+// Send adds message of userID to conversation they are a member of and
+// returns the conversation. The conversation stays readable after either
+// member blocks the other, but no more messages can be sent
+func (s *MessageService) Send(ctx context.Context, userID, conversationID string, m *message.Message) (*message.Conversation, error) {
+	conv, err := s.messageRepo.Get(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if other := conv.Other(userID); other != nil {
+		if err := s.checkBlocked(userID, other.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateMessage(ctx, s.mediaRepo, userID, m); err != nil {
+		return nil, err
+	}
+
+	m.ConversationID = conv.ID
+	m.SenderID = userID
+	if err := s.messageRepo.CreateMessage(ctx, m); err != nil {
+		return nil, err
+	}
+	if err := signMessages(ctx, s.storage, m); err != nil {
+		return nil, err
+	}
+
+	for _, member := range conv.Members {
+		if member.UserID != userID {
+			s.events.MessageSent(member.UserID, conv.ID, m.ID)
+		}
+	}
+	return conv, nil
+}
+
+// GetMessages retrieves a page of messages, newest first, with their
+// conversation and cursor of the next page. Only members can read a conversation
+func (s *MessageService) GetMessages(ctx context.Context, userID, conversationID, after string, limit int) (*message.Conversation, []*message.Message, string, error) {
+	conv, err := s.Get(ctx, userID, conversationID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	messages, err := s.messageRepo.GetMessages(ctx, conversationID, nil, after, limit)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err := signMessages(ctx, s.storage, messages...); err != nil {
+		return nil, nil, "", err
+	}
+
+	next := ""
+	if len(messages) == limit {
+		last := messages[len(messages)-1]
+		next = cursor.Encode(*last.CreatedAt, last.ID)
+	}
+
+	return conv, messages, next, nil
+}
+
+// MarkRead reads conversation up to its latest message, the other member
+// is told their messages were read
+func (s *MessageService) MarkRead(ctx context.Context, userID, conversationID string) error {
+	conv, err := s.messageRepo.Get(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.messageRepo.MarkRead(ctx, userID, conversationID); err != nil {
+		return err
+	}
+
+	for _, member := range conv.Members {
+		if member.UserID != userID {
+			s.events.ConversationRead(member.UserID, conv.ID)
+		}
+	}
+	return nil
+}
+
+// checkBlocked fails with message.ErrBlocked if either user blocks the other
+func (s *MessageService) checkBlocked(userID, otherID string) error {
+	blocked, err := s.userRepo.IsBlocked(userID, otherID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return message.ErrBlocked
+	}
+	return nil
+}
+
+// sign replaces media URLs of last messages of conversations by signed ones
+func (s *MessageService) sign(ctx context.Context, list ...*message.Conversation) error {
+	for _, conv := range list {
+		if conv.LastMessage == nil {
+			continue
+		}
+		if err := signMessages(ctx, s.storage, conv.LastMessage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signMessages replaces URLs of media attached to messages by signed ones,
+// message media are private and have no public URL
+func signMessages(ctx context.Context, st storage.Storage, messages ...*message.Message) error {
+	for _, m := range messages {
+		for i := range m.Media {
+			if err := signMedia(ctx, st, &m.Media[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateMessage trims body of m and checks it has content and its media
+// are uploads of ownerID not used elsewhere. Groups share it with direct messages
+func validateMessage(ctx context.Context, mediaRepo media.Repository, ownerID string, m *message.Message) error {
+	m.Body = strings.TrimSpace(m.Body)
+	if m.Body == "" && len(m.MediaIDs) == 0 {
+		return message.ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(m.Body) > message.MaxBodyLength {
+		return message.ErrBodyTooLong
+	}
+
+	ids := m.MediaIDs
+	if len(ids) == 0 {
+		return nil
+	}
+	if len(ids) > message.MaxMedia {
+		return message.ErrTooManyMedia
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return message.ErrMediaNotAvailable
+		}
+		seen[id] = true
+	}
+
+	list, err := mediaRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(list) != len(ids) {
+		return message.ErrMediaNotAvailable
+	}
+	for _, item := range list {
+		if item.OwnerID != ownerID || item.PostID != nil || item.MessageID != nil || !item.Private {
+			return message.ErrMediaNotAvailable
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+)
+
+type NotificationService struct {
+	repo   notification.Repository
+	events *Events
+	log    *logger.Logger
+}
+
+func NewNotificationService(repo notification.Repository, events *Events, log *logger.Logger) *NotificationService {
+	return &NotificationService{
+		repo:   repo,
+		events: events,
+		log:    log,
+	}
+}
+
+// Notify records event for its recipient. Users are not notified about
+// their own actions. Failures are only logged, the action which caused
+// the event has already succeeded
+func (s *NotificationService) Notify(ctx context.Context, e notification.Event) {
+	if e.RecipientID == "" || e.RecipientID == e.ActorID {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+
+	if err := s.repo.Add(ctx, &e); err != nil {
+		s.log.Error("saving notification failed", err.Error())
+		return
+	}
+	s.events.Notified(&e)
+}
+
+// List retrieves a page of notifications of userID, latest activity
+// first, with cursor of the next page and number of unread notifications
+func (s *NotificationService) List(ctx context.Context, userID, after string, limit int) ([]*notification.Notification, string, int64, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	list, err := s.repo.List(ctx, userID, after, limit)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	unread, err := s.repo.UnreadCount(ctx, userID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	next := ""
+	if len(list) == limit {
+		last := list[len(list)-1]
+		next = cursor.Encode(last.UpdatedAt, last.ID)
+	}
+
+	return list, next, unread, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id string) error {
+	return s.repo.MarkRead(ctx, userID, id, time.Now())
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID string) error {
+	return s.repo.MarkAllRead(ctx, userID, time.Now())
+}
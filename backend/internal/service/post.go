@@ -2,41 +2,285 @@ package service
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/critiq17/critiqal-site/internal/domain/bookmark"
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
 	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+	"github.com/critiq17/critiqal-site/internal/storage"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/critiq17/critiqal-site/pkg/markdown"
+	"github.com/critiq17/critiqal-site/pkg/unfurl"
 )
 
 type PostService struct {
-	postRepo post.Repository
+	postRepo     post.Repository
+	userRepo     user.Repository
+	mediaRepo    media.Repository
+	storage      storage.Storage
+	bookmarkRepo bookmark.Repository
+	previews     *PreviewService
+	views        *ViewCounter
+
+	notifications *NotificationService
+	events        *Events
+
+	// editWindow limits how long after publishing a post can be edited,
+	// zero allows edits at any time
+	editWindow time.Duration
+	// trashRetention is how long deleted posts can be restored
+	trashRetention time.Duration
 }
 
-func NewPostService(postRepo post.Repository) *PostService {
+func NewPostService(postRepo post.Repository, userRepo user.Repository, mediaRepo media.Repository, storage storage.Storage, bookmarkRepo bookmark.Repository, previews *PreviewService, views *ViewCounter, notifications *NotificationService, events *Events, editWindow, trashRetention time.Duration) *PostService {
 	return &PostService{
-		postRepo: postRepo,
+		postRepo:     postRepo,
+		userRepo:     userRepo,
+		mediaRepo:    mediaRepo,
+		storage:      storage,
+		bookmarkRepo: bookmarkRepo,
+		previews:     previews,
+		views:        views,
+		editWindow:   editWindow,
+
+		notifications:  notifications,
+		events:         events,
+		trashRetention: trashRetention,
+	}
+}
+
+func (s *PostService) Create(ctx context.Context, p *post.Post) error {
+	p.Kind = post.KindPost
+
+	switch p.Visibility {
+	case "":
+		p.Visibility = post.VisibilityPublic
+	case post.VisibilityPublic, post.VisibilityFollowers, post.VisibilityMentioned, post.VisibilityUnlisted:
+	default:
+		return post.ErrInvalidVisibility
+	}
+
+	// quote post, always reference the post which holds the content
+	var original *post.Post
+	if p.OriginalID != nil {
+		var err error
+		original, err = s.resolveOriginal(ctx, p.OwnerID, *p.OriginalID)
+		if err != nil {
+			return err
+		}
+		p.Kind = post.KindQuote
+		p.OriginalID = &original.ID
+	}
+
+	list, err := s.validateMedia(ctx, p.OwnerID, p.MediaIDs)
+	if err != nil {
+		return err
+	}
+	// media of posts with limited audience must not stay reachable by
+	// public URL, they are served by signed URLs like message media
+	if !p.IsShareable() {
+		for _, m := range list {
+			if err := makePrivate(ctx, s.mediaRepo, s.storage, m); err != nil {
+				return err
+			}
+		}
+	}
+	if err := validateSensitive(p, p.MediaIDs); err != nil {
+		return err
+	}
+
+	if p.Status == "" && p.PublishAt == nil {
+		p.Status = post.StatusPublished
+	}
+	if err := validateSchedule(p, time.Now()); err != nil {
+		return err
+	}
+	if err := validatePoll(p, time.Now()); err != nil {
+		return err
+	}
+
+	html, err := markdown.Render(p.Description)
+	if err != nil {
+		return err
+	}
+	p.DescriptionHTML = html
+	p.Tags = post.ParseHashtags(p.Description)
+	p.Mentions = s.resolveMentions(p.OwnerID, p.Description)
+
+	if err := s.postRepo.Create(ctx, p); err != nil {
+		return err
+	}
+	if p.IsPublished() {
+		s.notifyPublished(ctx, p, original)
+	}
+
+	// preview is usually ready by the time followers load the post
+	s.previews.Request(unfurl.FirstURL(p.Description))
+	return nil
+}
+
+func (s *PostService) Get(ctx context.Context, viewerID, id string) (*post.Post, error) {
+	p, err := s.Peek(ctx, viewerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.views.Record(viewerID, time.Now(), p)
+	return p, nil
+}
+
+// Peek retrieves post like Get without counting a view, it is used for
+// updates pushed to clients
+func (s *PostService) Peek(ctx context.Context, viewerID, id string) (*post.Post, error) {
+	p, err := s.postRepo.Get(ctx, viewerID, id)
+	if err != nil {
+		return nil, err
 	}
+
+	s.prepare(ctx, viewerID, p)
+	return p, nil
 }
 
-func (s *PostService) Create(ctx context.Context, post *post.Post) error {
-	return s.postRepo.Create(ctx, post)
+// GetForOwner retrieves published post or draft of userID, it is used
+// to authorize changes of a post
+func (s *PostService) GetForOwner(ctx context.Context, userID, id string) (*post.Post, error) {
+	p, err := s.postRepo.Get(ctx, userID, id)
+	if errors.Is(err, post.ErrNotFound) {
+		p, err = s.postRepo.GetDraft(ctx, userID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.prepare(ctx, userID, p)
+	return p, nil
 }
 
-func (s *PostService) Get(ctx context.Context, id string) (*post.Post, error) {
-	return s.postRepo.Get(ctx, id)
+// Update edits post of userID. Status is changed when p.Status or
+// p.PublishAt is set, published posts can't go back to drafts
+func (s *PostService) Update(ctx context.Context, userID, id string, p *post.Post) error {
+	existing, err := s.GetForOwner(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if existing.Kind == post.KindRepost {
+		return post.ErrNotEditable
+	}
+
+	attached := make([]string, len(existing.Media))
+	for i, m := range existing.Media {
+		attached[i] = m.ID
+	}
+	if err := validateSensitive(p, attached); err != nil {
+		return err
+	}
+	if s.editWindow > 0 && existing.IsPublished() && existing.CreatedAt != nil &&
+		time.Since(*existing.CreatedAt) > s.editWindow {
+		return post.ErrEditWindowClosed
+	}
+
+	if p.Status != "" || p.PublishAt != nil {
+		if existing.IsPublished() && p.Status != post.StatusPublished {
+			return post.ErrAlreadyPublished
+		}
+		if err := validateSchedule(p, time.Now()); err != nil {
+			return err
+		}
+
+		switch {
+		case existing.IsPublished():
+			p.Status = ""
+		case p.Status == post.StatusPublished:
+			now := time.Now()
+			p.CreatedAt = &now
+		}
+
+		// poll of a draft must still be open when the post goes out
+		if p.Status != "" && p.Status != post.StatusDraft && existing.Poll != nil {
+			publishAt := time.Now()
+			if p.PublishAt != nil {
+				publishAt = *p.PublishAt
+			}
+			if !existing.Poll.ClosesAt.After(publishAt) {
+				return post.ErrInvalidClosing
+			}
+		}
+	}
+
+	// description is replaced, so html, tags and mentions have to follow it
+	p.DescriptionHTML = ""
+	p.Tags = nil
+	p.Mentions = nil
+	if p.Description != "" {
+		html, err := markdown.Render(p.Description)
+		if err != nil {
+			return err
+		}
+		p.DescriptionHTML = html
+		p.Tags = post.ParseHashtags(p.Description)
+		p.Mentions = s.resolveMentions(userID, p.Description)
+	}
+
+	if err := s.postRepo.Update(ctx, id, p); err != nil {
+		return err
+	}
+	if !existing.IsPublished() && p.Status == post.StatusPublished {
+		if published, err := s.postRepo.GetPublished(ctx, []string{id}); err == nil && len(published) == 1 {
+			s.notifyPublished(ctx, published[0], published[0].Original)
+		}
+	}
+
+	if p.Description != "" {
+		s.previews.Request(unfurl.FirstURL(p.Description))
+	}
+	return nil
 }
 
-func (s *PostService) Update(ctx context.Context, id string, post *post.Post) error {
-	return s.postRepo.Update(ctx, id, post)
+// GetRevisions retrieves previous versions of a published post, newest first
+func (s *PostService) GetRevisions(ctx context.Context, viewerID, id string) ([]*post.Revision, error) {
+	if _, err := s.postRepo.Get(ctx, viewerID, id); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.postRepo.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// versions saved before markdown support have no html
+	for _, r := range revisions {
+		if r.DescriptionHTML == "" && r.Description != "" {
+			if html, err := markdown.Render(r.Description); err == nil {
+				r.DescriptionHTML = html
+			}
+		}
+	}
+
+	return revisions, nil
 }
 
 func (s *PostService) Delete(ctx context.Context, id string) error {
 	return s.postRepo.Delete(ctx, id)
 }
 
-func (s *PostService) GetPostsByUserID(ctx context.Context, user_id string) ([]*post.Post, error) {
-	return s.postRepo.GetPostsByUserID(ctx, user_id)
+func (s *PostService) GetPostsByUserID(ctx context.Context, viewerID, user_id string) ([]*post.Post, error) {
+	posts, err := s.postRepo.GetPostsByUserID(ctx, viewerID, user_id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.prepare(ctx, viewerID, posts...)
+	s.views.Record(viewerID, time.Now(), posts...)
+	return posts, nil
 }
 
-func (s *PostService) GetRecentPosts(ctx context.Context, limit int) ([]*post.Post, error) {
+func (s *PostService) GetRecentPosts(ctx context.Context, viewerID string, limit int) ([]*post.Post, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -44,5 +288,510 @@ func (s *PostService) GetRecentPosts(ctx context.Context, limit int) ([]*post.Po
 		limit = 100
 	}
 
-	return s.postRepo.GetRecent(ctx, limit)
+	posts, err := s.postRepo.GetRecent(ctx, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.prepare(ctx, viewerID, posts...)
+	s.views.Record(viewerID, time.Now(), posts...)
+	return posts, nil
+}
+
+// GetTrending retrieves posts of the latest trending ranking visible to viewerID
+func (s *PostService) GetTrending(ctx context.Context, viewerID string, limit int) ([]*post.Post, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	posts, err := s.postRepo.GetTrending(ctx, viewerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.prepare(ctx, viewerID, posts...)
+	s.views.Record(viewerID, time.Now(), posts...)
+	return posts, nil
+}
+
+// GetMentions retrieves a page of posts which mention userID and cursor
+// of the next page (empty when there are no more posts)
+func (s *PostService) GetMentions(ctx context.Context, userID, after string, limit int) ([]*post.Post, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	posts, err := s.postRepo.GetMentioning(ctx, userID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.prepare(ctx, userID, posts...)
+	s.views.Record(userID, time.Now(), posts...)
+	return posts, nextPostsCursor(posts, limit), nil
+}
+
+// GetPostsByTag retrieves a page of posts tagged with tag and cursor of
+// the next page (empty when there are no more posts)
+func (s *PostService) GetPostsByTag(ctx context.Context, viewerID, tag, after string, limit int) ([]*post.Post, string, error) {
+	tag, ok := post.NormalizeHashtag(tag)
+	if !ok {
+		return nil, "", post.ErrInvalidTag
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	posts, err := s.postRepo.GetByTag(ctx, viewerID, tag, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.prepare(ctx, viewerID, posts...)
+	s.views.Record(viewerID, time.Now(), posts...)
+	return posts, nextPostsCursor(posts, limit), nil
+}
+
+// Pin pins own published post to the top of the profile
+func (s *PostService) Pin(ctx context.Context, userID, id string) error {
+	return s.postRepo.Pin(ctx, userID, id, post.MaxPinned)
+}
+
+func (s *PostService) Unpin(ctx context.Context, userID, id string) error {
+	return s.postRepo.Unpin(ctx, userID, id)
+}
+
+// GetDrafts retrieves a page of drafts and scheduled posts of userID
+// and cursor of the next page (empty when there are no more posts)
+func (s *PostService) GetDrafts(ctx context.Context, userID, after string, limit int) ([]*post.Post, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	posts, err := s.postRepo.GetDrafts(ctx, userID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.prepare(ctx, userID, posts...)
+	return posts, nextPostsCursor(posts, limit), nil
+}
+
+// GetBookmarks retrieves a page of posts bookmarked by userID, newest
+// bookmark first, and cursor of the next page. Nil collectionID lists
+// bookmarks of all collections
+func (s *PostService) GetBookmarks(ctx context.Context, userID string, collectionID *string, after string, limit int) ([]*post.Post, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	bookmarks, err := s.bookmarkRepo.List(ctx, userID, collectionID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	posts := make([]*post.Post, len(bookmarks))
+	for i, b := range bookmarks {
+		posts[i] = b.Post
+	}
+	s.prepare(ctx, userID, posts...)
+	s.views.Record(userID, time.Now(), posts...)
+
+	// page is cut by bookmarks, so the cursor points to the last bookmark
+	next := ""
+	if len(bookmarks) == limit {
+		last := bookmarks[len(bookmarks)-1]
+		next = cursor.Encode(*last.CreatedAt, last.PostID)
+	}
+
+	return posts, next, nil
+}
+
+// PublishDue publishes all scheduled posts due at now and returns how
+// many were published
+func (s *PostService) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	const batch = 100
+
+	total := 0
+	for {
+		ids, err := s.postRepo.PublishDue(ctx, now, batch)
+		total += len(ids)
+		if len(ids) > 0 {
+			s.notifyScheduled(ctx, ids)
+		}
+		if err != nil || len(ids) < batch {
+			return total, err
+		}
+	}
+}
+
+// validateSchedule checks status of a post being saved. Setting only
+// PublishAt schedules the post, PublishAt is kept for scheduled posts only
+func validateSchedule(p *post.Post, now time.Time) error {
+	if p.Status == "" {
+		p.Status = post.StatusScheduled
+	}
+
+	switch p.Status {
+	case post.StatusDraft, post.StatusPublished:
+		p.PublishAt = nil
+	case post.StatusScheduled:
+		if p.PublishAt == nil || !p.PublishAt.After(now) {
+			return post.ErrInvalidPublishAt
+		}
+	default:
+		return post.ErrInvalidStatus
+	}
+
+	return nil
+}
+
+// validateSensitive trims content warning and checks sensitive media are
+// among attached ones
+func validateSensitive(p *post.Post, attached []string) error {
+	if p.ContentWarning != nil {
+		cw := strings.TrimSpace(*p.ContentWarning)
+		if utf8.RuneCountInString(cw) > post.MaxContentWarningLength {
+			return post.ErrContentWarning
+		}
+		p.ContentWarning = &cw
+	}
+
+	for _, id := range p.SensitiveMediaIDs {
+		if !slices.Contains(attached, id) {
+			return post.ErrSensitiveMedia
+		}
+	}
+
+	return nil
+}
+
+// validatePoll normalizes poll of a new post, poll has to stay open
+// for a while after the post is published
+func validatePoll(p *post.Post, now time.Time) error {
+	if p.Poll == nil {
+		return nil
+	}
+	if err := p.Poll.Normalize(); err != nil {
+		return err
+	}
+
+	publishAt := now
+	if p.PublishAt != nil {
+		publishAt = *p.PublishAt
+	}
+	if !p.Poll.ClosesAt.After(publishAt) {
+		return post.ErrInvalidClosing
+	}
+
+	return nil
+}
+
+// Vote saves choices of userID in poll of a post visible to them and
+// returns the post with updated tallies. Voting through a repost votes
+// in the original poll
+func (s *PostService) Vote(ctx context.Context, userID, id string, choices []int) (*post.Post, error) {
+	p, err := s.postRepo.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Kind == post.KindRepost && p.Original != nil {
+		p = p.Original
+	}
+
+	if p.Poll == nil {
+		return nil, post.ErrNoPoll
+	}
+	if p.Poll.IsClosed(time.Now()) {
+		return nil, post.ErrPollClosed
+	}
+	if err := p.Poll.ValidateChoices(choices); err != nil {
+		return nil, err
+	}
+
+	if err := s.postRepo.Vote(ctx, p.ID, userID, choices); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, userID, p.ID)
+}
+
+// nextPostsCursor returns cursor after the last post of a full page
+func nextPostsCursor(posts []*post.Post, limit int) string {
+	if len(posts) == 0 || len(posts) < limit {
+		return ""
+	}
+	last := posts[len(posts)-1]
+	return cursor.Encode(*last.CreatedAt, last.ID)
+}
+
+// resolveMentions keeps only mentions of existing users who don't block
+// authorID and aren't blocked by them, the rest of "@words" stay plain text
+func (s *PostService) resolveMentions(authorID, text string) []post.Mention {
+	resolved := []post.Mention{}
+	users := map[string]*user.User{}
+
+	for _, m := range post.ParseMentions(text) {
+		u, ok := users[m.Username]
+		if !ok {
+			u, _ = s.userRepo.GetUserByUsername(m.Username)
+			if u != nil {
+				if blocked, err := s.userRepo.IsBlocked(authorID, u.ID); err != nil || blocked {
+					u = nil
+				}
+			}
+			users[m.Username] = u
+		}
+		if u == nil {
+			continue
+		}
+
+		m.UserID = u.ID
+		m.Username = u.Username
+		resolved = append(resolved, m)
+	}
+
+	return resolved
+}
+
+// Repost boosts original post on behalf of userID. Reposting the same
+// post again returns the existing repost
+func (s *PostService) Repost(ctx context.Context, userID, originalID string) (*post.Post, error) {
+	original, err := s.resolveOriginal(ctx, userID, originalID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.postRepo.FindRepost(ctx, userID, original.ID)
+	if err == nil {
+		s.prepare(ctx, userID, existing)
+		return existing, nil
+	}
+	if !errors.Is(err, post.ErrNotFound) {
+		return nil, err
+	}
+
+	repost := &post.Post{
+		OwnerID:    userID,
+		Kind:       post.KindRepost,
+		OriginalID: &original.ID,
+		Visibility: post.VisibilityPublic,
+	}
+
+	if err := s.postRepo.Create(ctx, repost); err != nil {
+		// concurrent repost hit the unique index, return the winner
+		if existing, findErr := s.postRepo.FindRepost(ctx, userID, original.ID); findErr == nil {
+			s.prepare(ctx, userID, existing)
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	s.events.PostPublished(repost)
+	s.events.CountsChanged(original.ID, original.OwnerID)
+	s.notifications.Notify(ctx, notification.Event{
+		Type:        notification.TypeRepost,
+		RecipientID: original.OwnerID,
+		ActorID:     userID,
+		PostID:      &original.ID,
+	})
+
+	return s.Get(ctx, userID, repost.ID)
+}
+
+// notifyPublished announces published p to streams and notifies users
+// mentioned in it and, for quotes, the owner of original
+func (s *PostService) notifyPublished(ctx context.Context, p, original *post.Post) {
+	s.events.PostPublished(p)
+	for _, m := range p.Mentions {
+		s.notifications.Notify(ctx, notification.Event{
+			Type:        notification.TypeMention,
+			RecipientID: m.UserID,
+			ActorID:     p.OwnerID,
+			PostID:      &p.ID,
+		})
+	}
+
+	if p.Kind == post.KindQuote && original != nil {
+		s.events.CountsChanged(original.ID, original.OwnerID)
+		s.notifications.Notify(ctx, notification.Event{
+			Type:        notification.TypeRepost,
+			RecipientID: original.OwnerID,
+			ActorID:     p.OwnerID,
+			PostID:      &original.ID,
+		})
+	}
+}
+
+// notifyScheduled sends notifications of scheduled posts which were just published
+func (s *PostService) notifyScheduled(ctx context.Context, ids []string) {
+	posts, err := s.postRepo.GetPublished(ctx, ids)
+	if err != nil {
+		return
+	}
+	for _, p := range posts {
+		s.notifyPublished(ctx, p, p.Original)
+	}
+}
+
+// Unrepost removes repost of original post made by userID
+func (s *PostService) Unrepost(ctx context.Context, userID, originalID string) error {
+	original, err := s.resolveOriginal(ctx, userID, originalID)
+	if err != nil {
+		// original may already be deleted, drop the repost anyway
+		return s.postRepo.DeleteRepost(ctx, userID, originalID)
+	}
+
+	if err := s.postRepo.DeleteRepost(ctx, userID, original.ID); err != nil {
+		return err
+	}
+	s.events.CountsChanged(original.ID, original.OwnerID)
+	return nil
+}
+
+// resolveOriginal finds post which holds the content, so reposting
+// a repost references the underlying post. Only posts visible to
+// viewerID and open to everyone can be shared
+func (s *PostService) resolveOriginal(ctx context.Context, viewerID, id string) (*post.Post, error) {
+	original, err := s.postRepo.Get(ctx, viewerID, id)
+	if err != nil {
+		if errors.Is(err, post.ErrNotFound) {
+			return nil, post.ErrOriginalNotFound
+		}
+		return nil, err
+	}
+
+	if original.Kind == post.KindRepost {
+		if original.Original == nil {
+			return nil, post.ErrOriginalNotFound
+		}
+		original = original.Original
+	}
+
+	if !original.IsShareable() {
+		return nil, post.ErrNotShareable
+	}
+
+	return original, nil
+}
+
+// validateMedia checks media to attach are uploaded by ownerID and not
+// used yet and returns them
+func (s *PostService) validateMedia(ctx context.Context, ownerID string, ids []string) ([]*media.Media, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if len(ids) > post.MaxMedia {
+		return nil, post.ErrTooManyMedia
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return nil, post.ErrMediaNotAvailable
+		}
+		seen[id] = true
+	}
+
+	list, err := s.mediaRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) != len(ids) {
+		return nil, post.ErrMediaNotAvailable
+	}
+	for _, m := range list {
+		if m.OwnerID != ownerID || m.PostID != nil || m.MessageID != nil {
+			return nil, post.ErrMediaNotAvailable
+		}
+	}
+
+	return list, nil
+}
+
+// prepare completes posts loaded for viewerID before they are returned,
+// including embedded originals
+func (s *PostService) prepare(ctx context.Context, viewerID string, posts ...*post.Post) {
+	all := make([]*post.Post, 0, len(posts))
+	for _, p := range posts {
+		all = append(all, p)
+		if p.Original != nil {
+			all = append(all, p.Original)
+		}
+	}
+
+	ids := make([]string, len(all))
+	for i, p := range all {
+		ids[i] = p.ID
+	}
+	bookmarked, _ := s.bookmarkRepo.BookmarkedPostIDs(ctx, viewerID, ids)
+
+	var pollIDs []string
+	for _, p := range all {
+		if p.Poll != nil {
+			pollIDs = append(pollIDs, p.ID)
+		}
+	}
+	choices, _ := s.postRepo.PollChoices(ctx, viewerID, pollIDs)
+
+	// previews are looked up by the first link, so older posts get them too
+	links := make(map[*post.Post]string, len(all))
+	var urls []string
+	for _, p := range all {
+		if url := unfurl.FirstURL(p.Description); url != "" {
+			links[p] = url
+			urls = append(urls, url)
+		}
+	}
+	previews := s.previews.Lookup(ctx, urls)
+
+	// sensitive media stay collapsed unless the viewer opted in
+	expand := false
+	if viewerID != "" {
+		if viewer, err := s.userRepo.GetByID(viewerID); err == nil {
+			expand = viewer.Preferences.ExpandSensitiveMedia
+		}
+	}
+
+	now := time.Now()
+	for _, p := range all {
+		p.Bookmarked = bookmarked[p.ID]
+		if p.Poll != nil {
+			p.Poll.ApplyViewer(choices[p.ID], now)
+		}
+		p.LinkPreview = previews[links[p]]
+		p.MediaCollapsed = p.HasSensitiveMedia() && !expand
+		for i := range p.Media {
+			_ = signMedia(ctx, s.storage, &p.Media[i])
+		}
+		if p.OwnerID != viewerID {
+			p.ViewsCount = nil
+		}
+
+		// posts written before markdown support are rendered once and saved
+		if p.Description != "" && p.DescriptionHTML == "" {
+			html, err := markdown.Render(p.Description)
+			if err != nil {
+				continue
+			}
+			p.DescriptionHTML = html
+			_ = s.postRepo.SetDescriptionHTML(ctx, p.ID, html)
+		}
+	}
 }
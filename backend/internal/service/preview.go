@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/preview"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+	"github.com/critiq17/critiqal-site/pkg/unfurl"
+)
+
+// maxConcurrentUnfurls limits background fetches, links over the limit
+// are requested again by the next read
+const maxConcurrentUnfurls = 8
+
+// Unfurler fetches metadata of a page, it is implemented by unfurl.Fetcher
+type Unfurler interface {
+	Fetch(ctx context.Context, url string) (*unfurl.Preview, error)
+}
+
+// PreviewService keeps link previews of posts. Links are unfurled in the
+// background, posts get their preview once it is cached
+type PreviewService struct {
+	repo     preview.Repository
+	unfurler Unfurler
+	log      *logger.Logger
+
+	// ttl is how long cached previews are served before they are refreshed
+	ttl     time.Duration
+	timeout time.Duration
+
+	inflight sync.Map
+	slots    chan struct{}
+}
+
+func NewPreviewService(repo preview.Repository, unfurler Unfurler, log *logger.Logger, ttl, timeout time.Duration) *PreviewService {
+	return &PreviewService{
+		repo:     repo,
+		unfurler: unfurler,
+		log:      log,
+		ttl:      ttl,
+		timeout:  timeout,
+		slots:    make(chan struct{}, maxConcurrentUnfurls),
+	}
+}
+
+// Unfurl fetches url and caches the result, failed fetches are cached
+// as failed previews
+func (s *PreviewService) Unfurl(ctx context.Context, url string) (*preview.Preview, error) {
+	p := &preview.Preview{URL: url, FetchedAt: time.Now()}
+
+	page, err := s.unfurler.Fetch(ctx, url)
+	if err != nil {
+		p.Failed = true
+	} else {
+		p.Title = page.Title
+		p.Description = page.Description
+		p.ImageURL = page.ImageURL
+		p.SiteName = page.SiteName
+	}
+
+	if saveErr := s.repo.Save(ctx, p); saveErr != nil {
+		return nil, saveErr
+	}
+	return p, err
+}
+
+// Request unfurls url in the background unless it is already being fetched
+func (s *PreviewService) Request(url string) {
+	if url == "" {
+		return
+	}
+	if _, busy := s.inflight.LoadOrStore(url, struct{}{}); busy {
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.inflight.Delete(url)
+		return
+	}
+
+	go func() {
+		defer func() {
+			<-s.slots
+			s.inflight.Delete(url)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		if _, err := s.Unfurl(ctx, url); err != nil {
+			s.log.Warn("link preview failed", url, err.Error())
+		}
+	}()
+}
+
+// Lookup retrieves cached previews worth showing, keyed by URL. Missing
+// and stale previews are requested, stale ones are still returned
+func (s *PreviewService) Lookup(ctx context.Context, urls []string) map[string]*preview.Preview {
+	found := map[string]*preview.Preview{}
+	if len(urls) == 0 {
+		return found
+	}
+
+	cached, err := s.repo.GetByURLs(ctx, urls)
+	if err != nil {
+		return found
+	}
+
+	now := time.Now()
+	for _, url := range urls {
+		p, ok := cached[url]
+		if !ok || p.IsStale(now, s.ttl) {
+			s.Request(url)
+		}
+		if ok && !p.IsEmpty() {
+			found[url] = p
+		}
+	}
+	return found
+}
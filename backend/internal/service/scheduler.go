@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/pkg/logger"
+)
+
+// PostScheduler publishes scheduled posts. State is kept in the database
+// only, so posts which became due while no server was running are
+// published on the first tick and replicas can run it side by side
+type PostScheduler struct {
+	posts    *PostService
+	log      *logger.Logger
+	interval time.Duration
+}
+
+func NewPostScheduler(posts *PostService, log *logger.Logger, interval time.Duration) *PostScheduler {
+	return &PostScheduler{
+		posts: posts, log: log, interval: interval,
+	}
+}
+
+// Run publishes due posts every interval until ctx is done
+func (s *PostScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := s.posts.PublishDue(ctx, time.Now()); err != nil {
+				s.log.Error("publishing scheduled posts failed", err.Error())
+			} else if n > 0 {
+				s.log.Info("published scheduled posts", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/internal/domain/user"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+	"github.com/critiq17/critiqal-site/pkg/pubsub"
+)
+
+// StreamUpdate is an event prepared for a user. Post is set for post and
+// counts events, loaded with visibility of the user
+type StreamUpdate struct {
+	Event
+	Post *post.Post
+}
+
+// StreamService turns published events into updates of a single user:
+// new posts and counts of users they follow (and their own), their
+// notifications and messages
+type StreamService struct {
+	ps       pubsub.PubSub
+	posts    *PostService
+	userRepo user.Repository
+	log      *logger.Logger
+
+	// heartbeat is how often idle streams are pinged, proxies close silent connections
+	heartbeat time.Duration
+}
+
+func NewStreamService(ps pubsub.PubSub, posts *PostService, userRepo user.Repository, log *logger.Logger, heartbeat time.Duration) *StreamService {
+	return &StreamService{
+		ps:        ps,
+		posts:     posts,
+		userRepo:  userRepo,
+		log:       log,
+		heartbeat: heartbeat,
+	}
+}
+
+func (s *StreamService) Heartbeat() time.Duration {
+	return s.heartbeat
+}
+
+// Subscribe streams updates of userID until ctx is done, then the
+// returned channel is closed
+func (s *StreamService) Subscribe(ctx context.Context, userID string) (<-chan StreamUpdate, error) {
+	followees, err := s.followees(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, cancelFeed := s.ps.Subscribe(topicFeed)
+	own, cancelOwn := s.ps.Subscribe(userTopic(userID))
+	out := make(chan StreamUpdate)
+
+	go func() {
+		defer close(out)
+		defer cancelFeed()
+		defer cancelOwn()
+
+		for {
+			var data []byte
+			select {
+			case <-ctx.Done():
+				return
+			case data = <-feed:
+			case data = <-own:
+			}
+
+			var ev Event
+			if err := json.Unmarshal(data, &ev); err != nil {
+				continue
+			}
+
+			update, ok := s.prepare(ctx, userID, followees, ev)
+			if ev.Type == eventFollows {
+				if f, err := s.followees(userID); err == nil {
+					followees = f
+				}
+			}
+			if !ok {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- update:
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// prepare reports whether ev is sent to userID and loads its post
+func (s *StreamService) prepare(ctx context.Context, userID string, followees map[string]bool, ev Event) (StreamUpdate, bool) {
+	update := StreamUpdate{Event: ev}
+
+	switch ev.Type {
+	case EventNotification, EventMessage, EventRead:
+		return update, true
+	case EventPost, EventCounts:
+		if ev.OwnerID != userID && !followees[ev.OwnerID] {
+			return update, false
+		}
+		p, err := s.posts.Peek(ctx, userID, ev.PostID)
+		if err != nil {
+			return update, false
+		}
+		update.Post = p
+		return update, true
+	default:
+		return update, false
+	}
+}
+
+func (s *StreamService) followees(userID string) (map[string]bool, error) {
+	ids, err := s.userRepo.GetFolloweeIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	followees := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		followees[id] = true
+	}
+	return followees, nil
+}
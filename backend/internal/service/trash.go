@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/cursor"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+)
+
+// GetTrash retrieves posts userID deleted within the retention window,
+// most recently deleted first, and cursor of the next page
+func (s *PostService) GetTrash(ctx context.Context, userID, after string, limit int) ([]*post.Post, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	posts, err := s.postRepo.GetDeleted(ctx, userID, time.Now().Add(-s.trashRetention), after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.prepare(ctx, userID, posts...)
+
+	next := ""
+	if len(posts) == limit {
+		last := posts[len(posts)-1]
+		next = cursor.Encode(*last.DeletedAt, last.ID)
+	}
+
+	return posts, next, nil
+}
+
+// Restore brings back post userID deleted within the retention window.
+// Restored posts are not pinned again
+func (s *PostService) Restore(ctx context.Context, userID, id string) (*post.Post, error) {
+	if err := s.postRepo.Restore(ctx, userID, id, time.Now().Add(-s.trashRetention)); err != nil {
+		return nil, err
+	}
+
+	return s.GetForOwner(ctx, userID, id)
+}
+
+// PurgeTrash permanently deletes posts whose retention window ended at
+// now and returns how many were purged
+func (s *PostService) PurgeTrash(ctx context.Context, now time.Time) (int, error) {
+	const batch = 100
+
+	total := 0
+	for {
+		n, err := s.postRepo.PurgeDeleted(ctx, now.Add(-s.trashRetention), batch)
+		total += n
+		if err != nil || n < batch {
+			return total, err
+		}
+	}
+}
+
+// TrashPurger periodically purges posts deleted longer than the
+// retention window ago. Batches are claimed with SKIP LOCKED, so
+// replicas can run it side by side
+type TrashPurger struct {
+	posts    *PostService
+	log      *logger.Logger
+	interval time.Duration
+}
+
+func NewTrashPurger(posts *PostService, log *logger.Logger, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		posts: posts, log: log, interval: interval,
+	}
+}
+
+// Run purges expired posts every interval until ctx is done
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := p.posts.PurgeTrash(ctx, time.Now()); err != nil {
+				p.log.Error("purging deleted posts failed", err.Error())
+			} else if n > 0 {
+				p.log.Info("purged deleted posts", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/trending"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+)
+
+// TrendingService periodically ranks posts and hashtags into a
+// materialized ranking, reads never compute it
+type TrendingService struct {
+	repo     trending.Repository
+	params   trending.Params
+	log      *logger.Logger
+	interval time.Duration
+}
+
+func NewTrendingService(repo trending.Repository, params trending.Params, log *logger.Logger, interval time.Duration) *TrendingService {
+	return &TrendingService{
+		repo: repo, params: params, log: log, interval: interval,
+	}
+}
+
+// Run computes the ranking right away and then every interval until ctx is done
+func (s *TrendingService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Compute(ctx, time.Now()); err != nil {
+			s.log.Error("computing trending failed", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compute ranks posts and hashtags as of now and replaces the stored ranking
+func (s *TrendingService) Compute(ctx context.Context, now time.Time) error {
+	events, err := s.repo.Engagements(ctx, now.Add(-s.params.Lookback))
+	if err != nil {
+		return err
+	}
+
+	uses, err := s.repo.TagUses(ctx, now.Add(-s.params.MaxWindow()))
+	if err != nil {
+		return err
+	}
+
+	posts := trending.ScorePosts(events, now, s.params)
+	tags := trending.RankTags(uses, now, s.params)
+
+	return s.repo.Replace(ctx, posts, tags, now)
+}
+
+// GetTags retrieves hashtags trending over window, which has to be one
+// of the configured windows
+func (s *TrendingService) GetTags(ctx context.Context, window time.Duration, limit int) ([]trending.TagScore, error) {
+	if !s.params.HasWindow(window) {
+		return nil, trending.ErrInvalidWindow
+	}
+	if limit <= 0 || limit > s.params.MaxTags {
+		limit = s.params.MaxTags
+	}
+
+	return s.repo.GetTags(ctx, window, limit)
+}
+
+// Windows lists windows hashtags are ranked over
+func (s *TrendingService) Windows() []time.Duration {
+	return s.params.TagWindows
+}
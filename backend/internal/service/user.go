@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"mime/multipart"
 
+	"github.com/critiq17/critiqal-site/internal/domain/media"
+	"github.com/critiq17/critiqal-site/internal/domain/notification"
 	"github.com/critiq17/critiqal-site/internal/domain/user"
 	"github.com/critiq17/critiqal-site/internal/repository"
 	"github.com/critiq17/critiqal-site/internal/storage"
@@ -23,13 +24,16 @@ type User struct {
 }
 
 type UserService struct {
-	repo    user.Repository
-	storage storage.Storage
+	repo          user.Repository
+	mediaRepo     media.Repository
+	storage       storage.Storage
+	notifications *NotificationService
+	events        *Events
 }
 
-func NewUserService(repo user.Repository, storage storage.Storage) *UserService {
+func NewUserService(repo user.Repository, mediaRepo media.Repository, storage storage.Storage, notifications *NotificationService, events *Events) *UserService {
 	return &UserService{
-		repo: repo, storage: storage,
+		repo: repo, mediaRepo: mediaRepo, storage: storage, notifications: notifications, events: events,
 	}
 }
 
@@ -79,23 +83,111 @@ func (s *UserService) GetByUsername(username string) (*user.User, error) {
 	return u, nil
 }
 
+// UpdatePreferences replaces preferences of user id
+func (s *UserService) UpdatePreferences(id string, prefs user.Preferences) (*user.User, error) {
+	if err := s.repo.UpdatePreferences(id, prefs); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
 func (s *UserService) SetUserPhoto(id, photo_url string) error {
-	return s.repo.UpdatePhoto(id, photo_url)
+	return s.repo.UpdatePhoto(id, photo_url, nil, "")
 }
 
+// UploadUserPhoto re-encodes avatar without metadata, stores it with
+// thumbnails and sets it as user photo
 func (s *UserService) UploadUserPhoto(ctx context.Context, username string, file *multipart.FileHeader) (string, error) {
-	path := fmt.Sprintf("avatars/%s/%s", username, file.Filename)
+	if s.storage == nil {
+		return "", media.ErrStorageUnavailable
+	}
 
-	url, err := s.storage.UploadFile(ctx, file, path)
+	res, err := processUpload(file)
 	if err != nil {
 		return "", err
 	}
 
-	if err := s.repo.UpdatePhoto(username, url); err != nil {
+	blob, err := storeBlob(ctx, s.mediaRepo, s.storage, res, false)
+	if err != nil {
 		return "", err
 	}
 
-	return url, nil
+	thumbnails := make(map[int]string, len(blob.Thumbnails))
+	for _, t := range blob.Thumbnails {
+		thumbnails[t.Size] = t.URL
+	}
+
+	// previous photo is released, GC deletes it when nothing else uses it
+	if err := s.repo.UpdatePhoto(username, blob.URL, thumbnails, blob.Hash); err != nil {
+		return "", err
+	}
+
+	return blob.URL, nil
+}
+
+// Follow makes followerID follow user with username
+func (s *UserService) Follow(followerID, username string) error {
+	u, err := s.repo.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	if u.ID == followerID {
+		return user.ErrCannotFollowSelf
+	}
+
+	created, err := s.repo.Follow(followerID, u.ID)
+	if err != nil {
+		return err
+	}
+	// already following, don't notify again
+	if !created {
+		return nil
+	}
+	s.events.FollowsChanged(followerID)
+
+	s.notifications.Notify(context.Background(), notification.Event{
+		Type:        notification.TypeFollow,
+		RecipientID: u.ID,
+		ActorID:     followerID,
+	})
+	return nil
+}
+
+func (s *UserService) Unfollow(followerID, username string) error {
+	u, err := s.repo.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Unfollow(followerID, u.ID); err != nil {
+		return err
+	}
+	s.events.FollowsChanged(followerID)
+	return nil
+}
+
+// Block makes blockerID block user with username, they can't message
+// each other until the block is removed
+func (s *UserService) Block(blockerID, username string) error {
+	u, err := s.repo.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+	if u.ID == blockerID {
+		return user.ErrCannotBlockSelf
+	}
+
+	return s.repo.Block(blockerID, u.ID)
+}
+
+func (s *UserService) Unblock(blockerID, username string) error {
+	u, err := s.repo.GetUserByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Unblock(blockerID, u.ID)
 }
 
 func (s *UserService) SearchUsers(username string) ([]user.User, error) {
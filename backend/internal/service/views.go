@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/critiq17/critiqal-site/internal/domain/post"
+	"github.com/critiq17/critiqal-site/pkg/logger"
+)
+
+const (
+	// maxPendingViews bounds memory between flushes, views over it are dropped
+	maxPendingViews = 100000
+	// viewsBatchSize limits views written by one statement
+	viewsBatchSize = 1000
+)
+
+// ViewCounter counts views of posts. Views are buffered in memory and
+// written in batches every interval, a viewer is counted once per post
+// per window. Repeated views within a window are dropped in memory and,
+// across flushes and replicas, by the database
+type ViewCounter struct {
+	repo     post.Repository
+	log      *logger.Logger
+	interval time.Duration
+	window   time.Duration
+
+	mu      sync.Mutex
+	pending map[post.View]struct{}
+	pruned  time.Time
+}
+
+func NewViewCounter(repo post.Repository, log *logger.Logger, interval, window time.Duration) *ViewCounter {
+	return &ViewCounter{
+		repo:     repo,
+		log:      log,
+		interval: interval,
+		window:   window,
+		pending:  map[post.View]struct{}{},
+	}
+}
+
+// Record counts views of posts by viewerID at now. Owners viewing their
+// posts are not counted, reposts count as views of the original
+func (v *ViewCounter) Record(viewerID string, now time.Time, posts ...*post.Post) {
+	if viewerID == "" || len(posts) == 0 {
+		return
+	}
+	bucket := now.Truncate(v.window)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, p := range posts {
+		if p.Kind == post.KindRepost && p.Original != nil {
+			p = p.Original
+		}
+		if p.OwnerID == viewerID || !p.IsPublished() {
+			continue
+		}
+		if len(v.pending) >= maxPendingViews {
+			return
+		}
+		v.pending[post.View{PostID: p.ID, ViewerID: viewerID, Bucket: bucket}] = struct{}{}
+	}
+}
+
+// Run flushes buffered views every interval until ctx is done, the
+// last views are flushed on the way out
+func (v *ViewCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if _, err := v.Flush(context.Background(), time.Now()); err != nil {
+				v.log.Error("flushing post views failed", err.Error())
+			}
+			return
+		case <-ticker.C:
+			if _, err := v.Flush(ctx, time.Now()); err != nil {
+				v.log.Error("flushing post views failed", err.Error())
+			}
+		}
+	}
+}
+
+// Flush writes buffered views and returns how many of them were new.
+// Batches which fail are put back to be written by the next flush, the
+// rest are still written. Dedup records of past windows are pruned once
+// per window
+func (v *ViewCounter) Flush(ctx context.Context, now time.Time) (int64, error) {
+	v.mu.Lock()
+	pending := v.pending
+	v.pending = map[post.View]struct{}{}
+	v.mu.Unlock()
+
+	views := make([]post.View, 0, len(pending))
+	for view := range pending {
+		views = append(views, view)
+	}
+
+	var total int64
+	var failed error
+	for start := 0; start < len(views); start += viewsBatchSize {
+		end := min(start+viewsBatchSize, len(views))
+
+		n, err := v.repo.AddViews(ctx, views[start:end])
+		if err != nil {
+			v.requeue(views[start:end])
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		total += n
+	}
+	if failed != nil {
+		return total, failed
+	}
+
+	if bucket := now.Truncate(v.window); bucket.After(v.pruned) {
+		if err := v.repo.PruneViews(ctx, bucket); err != nil {
+			return total, err
+		}
+		v.pruned = bucket
+	}
+
+	return total, nil
+}
+
+// requeue puts back views which failed to be written, a batch is written
+// in one statement so none of them were counted
+func (v *ViewCounter) requeue(views []post.View) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, view := range views {
+		if len(v.pending) >= maxPendingViews {
+			return
+		}
+		v.pending[view] = struct{}{}
+	}
+}
@@ -1,24 +1,105 @@
 package storage
 
 import (
+	"crypto/rand"
 	"fmt"
 	"os"
+	"strings"
+)
+
+// Local storage defaults match the /uploads static route of the server
+const (
+	DefaultLocalPath = "./uploads"
+	DefaultLocalURL  = "/uploads/"
+	// DefaultLocalPrivatePath keeps private files out of the static root
+	DefaultLocalPrivatePath = "./private"
+
+	// DefaultSignedURL is the backend route which serves signed local files
+	DefaultSignedURL = "/api/media/signed/"
 )
 
 func NewStorageFromEnv() (Storage, error) {
 	storageType := os.Getenv("STORAGE_TYPE")
 
 	switch storageType {
-	case "local":
-		return NewLocalStorage(
-			os.Getenv("LOCAL_PATH"),
-			os.Getenv("LOCAL_URL"),
-		), nil
+	case "local", "":
+		return NewDefaultLocalStorage(), nil
 
 	case "s3":
-		return nil, fmt.Errorf("s3 not implemented")
+		return NewS3Storage(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			UseSSL:    envBool("S3_USE_SSL", true),
+			PathStyle: envBool("S3_PATH_STYLE", false),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
 
 	default:
 		return nil, fmt.Errorf("unknown storage type: %s", storageType)
 	}
 }
+
+// NewStorageFromEnvOrLocal is NewStorageFromEnv falling back to the default
+// local storage when the configured one can't be created. The error is
+// still returned, so it can be logged
+func NewStorageFromEnvOrLocal() (Storage, error) {
+	s, err := NewStorageFromEnv()
+	if err != nil || s == nil {
+		return NewDefaultLocalStorage(), err
+	}
+	return s, nil
+}
+
+// NewDefaultLocalStorage creates local storage from LOCAL_PATH, LOCAL_URL
+// and LOCAL_PRIVATE_PATH, falling back to defaults when they are not set
+func NewDefaultLocalStorage() *LocalStorage {
+	path := os.Getenv("LOCAL_PATH")
+	if path == "" {
+		path = DefaultLocalPath
+	}
+
+	url := os.Getenv("LOCAL_URL")
+	if url == "" {
+		url = DefaultLocalURL
+	}
+
+	private := os.Getenv("LOCAL_PRIVATE_PATH")
+	if private == "" {
+		private = DefaultLocalPrivatePath
+	}
+
+	s := NewLocalStorage(path, url)
+	s.PrivatePath = private
+	_ = os.MkdirAll(private, os.ModePerm)
+	s.SignedBaseURL = DefaultSignedURL
+	s.SigningKey = signingKey()
+
+	return s
+}
+
+// signingKey reads MEDIA_SIGNING_KEY. Without it a random key is used, so
+// signed URLs stop working after restart and aren't shared between replicas
+func signingKey() []byte {
+	if key := os.Getenv("MEDIA_SIGNING_KEY"); key != "" {
+		return []byte(key)
+	}
+
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return key
+}
+
+func envBool(key string, defaultValue bool) bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	switch v {
+	case "1", "true", "yes":
+		return true
+	case "0", "false", "no":
+		return false
+	default:
+		return defaultValue
+	}
+}
@@ -2,16 +2,32 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type LocalStorage struct {
 	BasePath string
 	BaseURL  string
+	// PrivatePath keeps files under PrivatePrefix outside of BasePath, so
+	// the public static route can't reach them. Empty keeps them in BasePath
+	PrivatePath string
+
+	// SigningKey signs URLs issued by SignedURL, SignedBaseURL is the
+	// base URL of the backend route which verifies them
+	SigningKey    []byte
+	SignedBaseURL string
 }
 
 func NewLocalStorage(basePath, baseURL string) *LocalStorage {
@@ -28,11 +44,6 @@ func NewLocalStorage(basePath, baseURL string) *LocalStorage {
 func (s *LocalStorage) UploadFile(ctx context.Context,
 	file *multipart.FileHeader, path string) (string, error) {
 
-	dst := filepath.Join(s.BasePath, path)
-	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
-		return "", fmt.Errorf("create dir: %w", err)
-	}
-
 	src, err := file.Open()
 	if err != nil {
 		return "", fmt.Errorf("open file: %w", err)
@@ -40,6 +51,32 @@ func (s *LocalStorage) UploadFile(ctx context.Context,
 
 	defer src.Close()
 
+	return s.Put(ctx, path, src, file.Header.Get("Content-Type"))
+}
+
+// file returns where path is stored on disk
+func (s *LocalStorage) file(p string) string {
+	rel := strings.TrimPrefix(filepath.ToSlash(p), "/")
+	if s.PrivatePath != "" && strings.HasPrefix(rel, PrivatePrefix) {
+		return filepath.Join(s.PrivatePath, filepath.FromSlash(strings.TrimPrefix(rel, PrivatePrefix)))
+	}
+	return filepath.Join(s.BasePath, filepath.FromSlash(rel))
+}
+
+// Put writes content to BasePath/path, content type is implied by extension
+// when the file is served. Private files are written to PrivatePath and
+// have no URL, they are read through SignedURL
+func (s *LocalStorage) Put(ctx context.Context, path string, src io.Reader, contentType string) (string, error) {
+	path, err := cleanPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	dst := s.file(path)
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return "", fmt.Errorf("create dir: %w", err)
+	}
+
 	out, err := os.Create(dst)
 	if err != nil {
 		return "", fmt.Errorf("create dst: %w", err)
@@ -51,11 +88,88 @@ func (s *LocalStorage) UploadFile(ctx context.Context,
 		return "", fmt.Errorf("copy: %w", err)
 	}
 
+	if strings.HasPrefix(path, PrivatePrefix) {
+		return "", nil
+	}
 	url := fmt.Sprintf("%s%s", s.BaseURL, path)
 	return url, nil
 }
 
 func (s *LocalStorage) DeleteFile(ctx context.Context, path string) error {
-	dst := filepath.Join(s.BasePath, path)
+	path, err := cleanPath(path)
+	if err != nil {
+		return err
+	}
+
+	dst := s.file(path)
 	return os.Remove(dst)
 }
+
+func (s *LocalStorage) Copy(ctx context.Context, src, dst string) (string, error) {
+	src, err := cleanPath(src)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(s.file(src))
+	if err != nil {
+		return "", fmt.Errorf("open src: %w", err)
+	}
+
+	defer in.Close()
+
+	return s.Put(ctx, dst, in, "")
+}
+
+// SignedURL issues HMAC signed URL served by the backend route at SignedBaseURL
+func (s *LocalStorage) SignedURL(ctx context.Context, p string, ttl time.Duration) (string, error) {
+	if len(s.SigningKey) == 0 {
+		return "", fmt.Errorf("signing key is not configured")
+	}
+
+	p, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(clampTTL(ttl)).Unix()
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", s.sign(p, expires))
+
+	return s.SignedBaseURL + p + "?" + q.Encode(), nil
+}
+
+// VerifySigned checks signature issued by SignedURL and returns the file on disk
+func (s *LocalStorage) VerifySigned(p string, expires int64, signature string) (string, error) {
+	if len(s.SigningKey) == 0 || time.Now().Unix() > expires {
+		return "", ErrInvalidSignature
+	}
+
+	p, err := cleanPath(p)
+	if err != nil {
+		return "", err
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(p, expires))) {
+		return "", ErrInvalidSignature
+	}
+
+	return s.file(p), nil
+}
+
+func (s *LocalStorage) sign(p string, expires int64) string {
+	mac := hmac.New(sha256.New, s.SigningKey)
+	mac.Write([]byte(p + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cleanPath rejects paths which could point outside of storage
+func cleanPath(p string) (string, error) {
+	p = strings.TrimPrefix(p, "/")
+	if p == "" || path.Clean(p) != p || strings.HasPrefix(p, "../") || p == ".." {
+		return "", ErrInvalidPath
+	}
+	return p, nil
+}
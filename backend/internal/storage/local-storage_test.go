@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStorageKeepsPrivateFilesOutOfBasePath(t *testing.T) {
+	base, private := t.TempDir(), t.TempDir()
+	s := NewLocalStorage(base, "/uploads/")
+	s.PrivatePath = private
+	s.SigningKey = []byte("key")
+	s.SignedBaseURL = "/api/media/signed/"
+	ctx := context.Background()
+
+	if _, err := s.Put(ctx, PrivatePrefix+"messages/a.png", strings.NewReader("secret"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "private", "messages", "a.png")); !os.IsNotExist(err) {
+		t.Errorf("private file was written under base path")
+	}
+	if _, err := os.Stat(filepath.Join(private, "messages", "a.png")); err != nil {
+		t.Errorf("private file is missing in private path: %v", err)
+	}
+
+	signed, err := s.SignedURL(ctx, PrivatePrefix+"messages/a.png", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	u, _ := url.Parse(signed)
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+
+	file, err := s.VerifySigned(strings.TrimPrefix(u.Path, s.SignedBaseURL), expires, u.Query().Get("signature"))
+	if err != nil {
+		t.Fatalf("VerifySigned: %v", err)
+	}
+	if file != filepath.Join(private, "messages", "a.png") {
+		t.Errorf("VerifySigned file = %s, want it in private path", file)
+	}
+
+	if err := s.DeleteFile(ctx, PrivatePrefix+"messages/a.png"); err != nil {
+		t.Errorf("DeleteFile: %v", err)
+	}
+}
+
+func TestLocalStorageCopiesToPrivatePath(t *testing.T) {
+	base, private := t.TempDir(), t.TempDir()
+	s := NewLocalStorage(base, "/uploads/")
+	s.PrivatePath = private
+	ctx := context.Background()
+
+	if _, err := s.Put(ctx, "media/a.png", strings.NewReader("image"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := s.Copy(ctx, "media/a.png", PrivatePrefix+"media/a.png"); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(private, "media", "a.png"))
+	if err != nil || string(got) != "image" {
+		t.Errorf("copy = %q, %v; want image in private path", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "media", "a.png")); err != nil {
+		t.Errorf("source file is missing after Copy: %v", err)
+	}
+}
+
+func TestLocalStorageRejectsPathsOutsideStorage(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "uploads")
+	s := NewLocalStorage(base, "/uploads/")
+	s.PrivatePath = filepath.Join(root, "private")
+	ctx := context.Background()
+
+	outside := filepath.Join(root, "outside")
+	if err := os.WriteFile(outside, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"../outside", "media/../../outside", PrivatePrefix + "../outside", "/../outside", "media/./a.png"} {
+		if _, err := s.Put(ctx, p, strings.NewReader("x"), "image/png"); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Put(%q) error = %v, want %v", p, err, ErrInvalidPath)
+		}
+		if err := s.DeleteFile(ctx, p); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("DeleteFile(%q) error = %v, want %v", p, err, ErrInvalidPath)
+		}
+		if _, err := s.Copy(ctx, p, "media/a.png"); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("Copy(%q) error = %v, want %v", p, err, ErrInvalidPath)
+		}
+	}
+
+	if got, err := os.ReadFile(outside); err != nil || string(got) != "keep" {
+		t.Errorf("file outside storage = %q, %v; want it untouched", got, err)
+	}
+}
+
+func TestLocalStoragePrivateFilesHaveNoURL(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/uploads/")
+	s.PrivatePath = t.TempDir()
+
+	u, err := s.Put(context.Background(), PrivatePrefix+"media/a.png", strings.NewReader("x"), "image/png")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if u != "" {
+		t.Errorf("Put URL of private file = %s, want none", u)
+	}
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Config struct {
+	// Endpoint is host[:port] of S3 API, a scheme may be given to choose
+	// between http and https, e.g. "http://minio:9000"
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PathStyle addresses objects as endpoint/bucket/key (MinIO and most
+	// self-hosted servers), otherwise as bucket.endpoint/key
+	PathStyle bool
+	// PublicURL is base URL objects are served from, e.g. a CDN. Built
+	// from endpoint and bucket when empty. Objects under PrivatePrefix get
+	// no public URL, but a public-read bucket still serves them to anyone
+	// who knows the key: its policy must exclude private/*
+	PublicURL string
+	// Transport overrides http transport, nil means default
+	Transport http.RoundTripper
+}
+
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+
+	endpoint := cfg.Endpoint
+	secure := cfg.UseSSL
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		endpoint = u.Host
+		secure = u.Scheme == "https"
+	}
+
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       secure,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+		Transport:    cfg.Transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create s3 client: %w", err)
+	}
+
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		if cfg.PathStyle {
+			publicURL = fmt.Sprintf("%s://%s/%s/", scheme, endpoint, cfg.Bucket)
+		} else {
+			publicURL = fmt.Sprintf("%s://%s.%s/", scheme, cfg.Bucket, endpoint)
+		}
+	}
+	if !strings.HasSuffix(publicURL, "/") {
+		publicURL += "/"
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    cfg.Bucket,
+		publicURL: publicURL,
+	}, nil
+}
+
+func (s *S3Storage) UploadFile(ctx context.Context, file *multipart.FileHeader, path string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+
+	defer src.Close()
+
+	return s.put(ctx, path, src, file.Size, file.Header.Get("Content-Type"))
+}
+
+func (s *S3Storage) Put(ctx context.Context, path string, r io.Reader, contentType string) (string, error) {
+	// unknown size makes client buffer a whole multipart part in memory
+	size := int64(-1)
+	if l, ok := r.(interface{ Len() int }); ok {
+		size = int64(l.Len())
+	}
+
+	return s.put(ctx, path, r, size, contentType)
+}
+
+func (s *S3Storage) put(ctx context.Context, path string, r io.Reader, size int64, contentType string) (string, error) {
+	key := strings.TrimPrefix(path, "/")
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("put object: %w", err)
+	}
+
+	return s.url(key), nil
+}
+
+// url returns public URL of key, private objects have none and are read
+// through SignedURL
+func (s *S3Storage) url(key string) string {
+	if strings.HasPrefix(key, PrivatePrefix) {
+		return ""
+	}
+	return s.publicURL + key
+}
+
+func (s *S3Storage) DeleteFile(ctx context.Context, path string) error {
+	return s.client.RemoveObject(ctx, s.bucket, strings.TrimPrefix(path, "/"), minio.RemoveObjectOptions{})
+}
+
+// Copy duplicates object on the server, content type is kept
+func (s *S3Storage) Copy(ctx context.Context, src, dst string) (string, error) {
+	key := strings.TrimPrefix(dst, "/")
+
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: key},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: strings.TrimPrefix(src, "/")},
+	)
+	if err != nil {
+		return "", fmt.Errorf("copy object: %w", err)
+	}
+
+	return s.url(key), nil
+}
+
+// SignedURL issues presigned GET URL of the object
+func (s *S3Storage) SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, strings.TrimPrefix(path, "/"), clampTTL(ttl), nil)
+	if err != nil {
+		return "", fmt.Errorf("presign object: %w", err)
+	}
+	return u.String(), nil
+}
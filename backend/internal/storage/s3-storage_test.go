@@ -0,0 +1,405 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-process S3 server keeping objects in memory. It accepts
+// both path-style and virtual-host requests, signatures are not checked
+type fakeS3 struct {
+	*httptest.Server
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	// hosts are Host headers of received requests
+	hosts []string
+}
+
+func newFakeS3(t *testing.T, bucket string) *fakeS3 {
+	f := &fakeS3{
+		bucket:  bucket,
+		objects: map[string][]byte{},
+		types:   map[string]string{},
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// objectKey resolves key of request, bucket is the first host label in
+// virtual-host style or the first path segment in path-style
+func (f *fakeS3) objectKey(r *http.Request) (string, bool) {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	if strings.HasPrefix(r.Host, f.bucket+".") {
+		return p, true
+	}
+	if strings.HasPrefix(p, f.bucket+"/") {
+		return strings.TrimPrefix(p, f.bucket+"/"), true
+	}
+	return "", false
+}
+
+func (f *fakeS3) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.hosts = append(f.hosts, r.Host)
+	f.mu.Unlock()
+
+	key, ok := f.objectKey(r)
+	if !ok || key == "" {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			f.copyObject(w, key, src)
+			return
+		}
+
+		body, err := readPayload(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		f.mu.Unlock()
+		w.Header().Set("ETag", `"etag"`)
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet:
+		if r.URL.Query().Get("X-Amz-Signature") == "" && r.Header.Get("Authorization") == "" {
+			http.Error(w, "AccessDenied", http.StatusForbidden)
+			return
+		}
+		f.mu.Lock()
+		body, ok := f.objects[key]
+		f.mu.Unlock()
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(body)
+
+	case http.MethodDelete:
+		f.mu.Lock()
+		delete(f.objects, key)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+// copyObject copies object named by X-Amz-Copy-Source, "/bucket/key"
+func (f *fakeS3) copyObject(w http.ResponseWriter, key, src string) {
+	src, _ = url.PathUnescape(src)
+	srcKey, ok := strings.CutPrefix(strings.TrimPrefix(src, "/"), f.bucket+"/")
+
+	f.mu.Lock()
+	body, found := f.objects[srcKey]
+	if ok && found {
+		f.objects[key] = body
+		f.types[key] = f.types[srcKey]
+	}
+	f.mu.Unlock()
+
+	if !ok || !found {
+		http.Error(w, "NoSuchKey", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprint(w, `<CopyObjectResult><LastModified>2026-01-01T00:00:00.000Z</LastModified><ETag>"etag"</ETag></CopyObjectResult>`)
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, ok := f.objects[key]
+	return body, ok
+}
+
+func (f *fakeS3) contentType(key string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.types[key]
+}
+
+func (f *fakeS3) lastHost() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.hosts[len(f.hosts)-1]
+}
+
+// readPayload reads request body, decoding aws-chunked bodies which the
+// client sends over plain http
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// transport sends every connection to the fake server, so virtual-host
+// names like bucket.s3.test resolve to it
+func (f *fakeS3) transport() *http.Transport {
+	addr := f.Listener.Addr().String()
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+func newTestS3Storage(t *testing.T, f *fakeS3, pathStyle bool) *S3Storage {
+	t.Helper()
+
+	endpoint := f.Listener.Addr().String()
+	if !pathStyle {
+		_, port, _ := net.SplitHostPort(endpoint)
+		endpoint = "s3.test:" + port
+	}
+
+	s, err := NewS3Storage(S3Config{
+		Endpoint:  "http://" + endpoint,
+		Region:    "us-east-1",
+		Bucket:    f.bucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: pathStyle,
+		Transport: f.transport(),
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	return s
+}
+
+func TestS3Storage(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		pathStyle bool
+	}{
+		{"path style", true},
+		{"virtual host", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeS3(t, "media")
+			s := newTestS3Storage(t, f, tc.pathStyle)
+			ctx := context.Background()
+
+			content := []byte("image content")
+			u, err := s.Put(ctx, "/posts/a.png", bytes.NewReader(content), "image/png")
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+
+			got, ok := f.object("posts/a.png")
+			if !ok || !bytes.Equal(got, content) {
+				t.Fatalf("stored object = %q, %v; want %q", got, ok, content)
+			}
+			if ct := f.contentType("posts/a.png"); ct != "image/png" {
+				t.Errorf("content type = %q, want image/png", ct)
+			}
+
+			host := f.lastHost()
+			if tc.pathStyle && strings.HasPrefix(host, "media.") {
+				t.Errorf("path-style request was sent to %s", host)
+			}
+			if !tc.pathStyle && !strings.HasPrefix(host, "media.s3.test") {
+				t.Errorf("virtual-host request was sent to %s", host)
+			}
+
+			wantURL := "http://" + host + "/posts/a.png"
+			if tc.pathStyle {
+				wantURL = "http://" + host + "/media/posts/a.png"
+			}
+			if u != wantURL {
+				t.Errorf("Put URL = %s, want %s", u, wantURL)
+			}
+
+			signed, err := s.SignedURL(ctx, "posts/a.png", time.Minute)
+			if err != nil {
+				t.Fatalf("SignedURL: %v", err)
+			}
+			parsed, err := url.Parse(signed)
+			if err != nil {
+				t.Fatalf("parse signed URL: %v", err)
+			}
+			if parsed.Query().Get("X-Amz-Expires") != "60" {
+				t.Errorf("X-Amz-Expires = %s, want 60", parsed.Query().Get("X-Amz-Expires"))
+			}
+
+			client := &http.Client{Transport: f.transport()}
+			res, err := client.Get(signed)
+			if err != nil {
+				t.Fatalf("GET signed URL: %v", err)
+			}
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK || !bytes.Equal(body, content) {
+				t.Errorf("GET signed URL = %d %q, want 200 %q", res.StatusCode, body, content)
+			}
+
+			if err := s.DeleteFile(ctx, "/posts/a.png"); err != nil {
+				t.Fatalf("DeleteFile: %v", err)
+			}
+			if _, ok := f.object("posts/a.png"); ok {
+				t.Error("object still exists after DeleteFile")
+			}
+		})
+	}
+}
+
+func TestS3StoragePrivateObjectsHaveNoPublicURL(t *testing.T) {
+	f := newFakeS3(t, "media")
+	s := newTestS3Storage(t, f, true)
+	ctx := context.Background()
+
+	u, err := s.Put(ctx, PrivatePrefix+"media/a.png", strings.NewReader("secret"), "image/png")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if u != "" {
+		t.Errorf("Put URL of private object = %s, want none", u)
+	}
+
+	u, err = s.Copy(ctx, PrivatePrefix+"media/a.png", PrivatePrefix+"media/b.png")
+	if err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	if u != "" {
+		t.Errorf("Copy URL of private object = %s, want none", u)
+	}
+
+	signed, err := s.SignedURL(ctx, PrivatePrefix+"media/a.png", time.Minute)
+	if err != nil || signed == "" {
+		t.Errorf("SignedURL = %q, %v; private objects are read through it", signed, err)
+	}
+}
+
+func TestS3StorageCopy(t *testing.T) {
+	f := newFakeS3(t, "media")
+	s := newTestS3Storage(t, f, true)
+	ctx := context.Background()
+
+	if _, err := s.Put(ctx, "media/a.png", strings.NewReader("image"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := s.Copy(ctx, "media/a.png", PrivatePrefix+"media/a.png"); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+
+	got, ok := f.object(PrivatePrefix + "media/a.png")
+	if !ok || string(got) != "image" {
+		t.Errorf("copied object = %q, %v; want image", got, ok)
+	}
+	if ct := f.contentType(PrivatePrefix + "media/a.png"); ct != "image/png" {
+		t.Errorf("content type of copy = %q, want image/png", ct)
+	}
+	if _, ok := f.object("media/a.png"); !ok {
+		t.Error("source object was removed by Copy")
+	}
+}
+
+func TestS3StorageSignedURLClampsTTL(t *testing.T) {
+	f := newFakeS3(t, "media")
+	s := newTestS3Storage(t, f, true)
+
+	signed, err := s.SignedURL(context.Background(), "a.png", 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+
+	parsed, _ := url.Parse(signed)
+	want := strconv.Itoa(int(MaxSignedURLTTL / time.Second))
+	if got := parsed.Query().Get("X-Amz-Expires"); got != want {
+		t.Errorf("X-Amz-Expires = %s, want %s", got, want)
+	}
+}
+
+func TestNewStorageFromEnvOrLocalFallsBack(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+	}{
+		{"s3 without endpoint", map[string]string{"STORAGE_TYPE": "s3", "S3_ENDPOINT": "", "S3_BUCKET": "media"}},
+		{"s3 without bucket", map[string]string{"STORAGE_TYPE": "s3", "S3_ENDPOINT": "localhost:9000", "S3_BUCKET": ""}},
+		{"unknown type", map[string]string{"STORAGE_TYPE": "ftp"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("LOCAL_PATH", dir)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			s, err := NewStorageFromEnvOrLocal()
+			if err == nil {
+				t.Error("expected error of the configured storage")
+			}
+			local, ok := s.(*LocalStorage)
+			if !ok {
+				t.Fatalf("storage = %T, want *LocalStorage", s)
+			}
+			if local.BasePath != dir {
+				t.Errorf("BasePath = %s, want %s", local.BasePath, dir)
+			}
+		})
+	}
+}
+
+func TestNewStorageFromEnvOrLocalUsesS3(t *testing.T) {
+	t.Setenv("STORAGE_TYPE", "s3")
+	t.Setenv("S3_ENDPOINT", "http://localhost:9000")
+	t.Setenv("S3_BUCKET", "media")
+
+	s, err := NewStorageFromEnvOrLocal()
+	if err != nil {
+		t.Fatalf("NewStorageFromEnvOrLocal: %v", err)
+	}
+	if _, ok := s.(*S3Storage); !ok {
+		t.Errorf("storage = %T, want *S3Storage", s)
+	}
+}
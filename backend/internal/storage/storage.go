@@ -2,10 +2,47 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
 	"mime/multipart"
+	"time"
+)
+
+// PrivatePrefix is where files which must be accessed only through
+// signed URLs are stored, it is not served publicly
+const PrivatePrefix = "private/"
+
+// MaxSignedURLTTL is the longest lifetime of a signed URL, presigned S3
+// URLs can't live longer
+const MaxSignedURLTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	ErrInvalidPath      = errors.New("invalid path")
 )
 
 type Storage interface {
 	UploadFile(ctx context.Context, file *multipart.FileHeader, path string) (string, error)
+	// Put stores content of r under path and returns its public URL,
+	// files under PrivatePrefix have none
+	Put(ctx context.Context, path string, r io.Reader, contentType string) (string, error)
 	DeleteFile(ctx context.Context, path string) error
+	// Copy duplicates file at src to dst and returns URL of the copy like Put
+	Copy(ctx context.Context, src, dst string) (string, error)
+	// SignedURL issues URL to the file at path which stops working after ttl
+	SignedURL(ctx context.Context, path string, ttl time.Duration) (string, error)
+}
+
+// SignatureVerifier is implemented by storages which serve files of
+// their signed URLs through the backend
+type SignatureVerifier interface {
+	// VerifySigned checks signature of path and returns file to serve
+	VerifySigned(path string, expires int64, signature string) (string, error)
+}
+
+func clampTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > MaxSignedURLTTL {
+		return MaxSignedURLTTL
+	}
+	return ttl
 }
@@ -0,0 +1,38 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalid = errors.New("invalid cursor")
+
+// Encode builds an opaque pagination cursor from the position of the last
+// item of a page (its timestamp and ID)
+func Encode(t time.Time, id string) string {
+	raw := strconv.FormatInt(t.UnixNano(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses a cursor produced by Encode
+func Decode(c string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return time.Time{}, "", ErrInvalid
+	}
+
+	ts, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", ErrInvalid
+	}
+
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, "", ErrInvalid
+	}
+
+	return time.Unix(0, nanos), id, nil
+}
@@ -0,0 +1,65 @@
+package imaging
+
+import "errors"
+
+var errBadGIF = errors.New("malformed gif")
+
+// gifFrameCount walks GIF block structure without decoding pixels, so
+// the size of an animation is known before it is decoded
+func gifFrameCount(data []byte) (int, error) {
+	// header and logical screen descriptor
+	if len(data) < 13 {
+		return 0, errBadGIF
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 * (1 << ((flags & 0x07) + 1))
+	}
+
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension: introducer, label, sub-blocks
+			var err error
+			if i, err = skipSubBlocks(data, i+2); err != nil {
+				return 0, err
+			}
+		case 0x2C: // image descriptor
+			if i+10 > len(data) {
+				return 0, errBadGIF
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 * (1 << ((flags & 0x07) + 1))
+			}
+			// LZW minimum code size, then image data sub-blocks
+			var err error
+			if i, err = skipSubBlocks(data, i+1); err != nil {
+				return 0, err
+			}
+			frames++
+		case 0x3B: // trailer
+			return frames, nil
+		default:
+			return 0, errBadGIF
+		}
+	}
+
+	// some encoders omit the trailer
+	return frames, nil
+}
+
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, errBadGIF
+		}
+		n := int(data[i])
+		i++
+		if n == 0 {
+			return i, nil
+		}
+		i += n
+	}
+}
@@ -0,0 +1,249 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrTooLarge        = errors.New("image is too large")
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+	ErrInvalid         = errors.New("invalid image")
+)
+
+// ThumbnailSizes are bounding boxes (in px) of generated thumbnails
+var ThumbnailSizes = []int{64, 256, 1024}
+
+type Options struct {
+	// MaxBytes limits encoded input size
+	MaxBytes int64
+	// MaxDimension limits width and height separately
+	MaxDimension int
+	// MaxPixels limits decoded size of all frames together, it guards
+	// against decompression bombs which are small files with huge dimensions
+	MaxPixels int
+	// JPEGQuality is used for re-encoded JPEG images and thumbnails
+	JPEGQuality int
+}
+
+var DefaultOptions = Options{
+	MaxBytes:     10 << 20,
+	MaxDimension: 12000,
+	MaxPixels:    50_000_000,
+	JPEGQuality:  88,
+}
+
+// Image is an encoded image ready to be stored
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Result of processing an upload. Thumbnails are keyed by ThumbnailSizes
+type Result struct {
+	Original   Image
+	Thumbnails map[int]Image
+}
+
+// Process verifies the real type of uploaded image, rejects images which
+// are too large to decode safely and re-encodes it, which drops EXIF, GPS
+// and any other metadata. JPEG orientation is applied to pixels before
+// metadata is dropped. WebP is re-encoded as PNG since there is no encoder
+func Process(r io.Reader, opts Options) (*Result, error) {
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > opts.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	frames := 1
+	if contentType == "image/gif" {
+		if frames, err = gifFrameCount(data); err != nil {
+			return nil, ErrInvalid
+		}
+	}
+	if err := checkDimensions(cfg, frames, opts); err != nil {
+		return nil, err
+	}
+
+	switch contentType {
+	case "image/gif":
+		return processGIF(data, opts)
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		img = applyOrientation(img, jpegOrientation(data))
+		return encodeResult(img, "image/jpeg", opts)
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		return encodeResult(img, "image/png", opts)
+	default:
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		return encodeResult(img, "image/png", opts)
+	}
+}
+
+func checkDimensions(cfg image.Config, frames int, opts Options) error {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return ErrInvalid
+	}
+	if cfg.Width > opts.MaxDimension || cfg.Height > opts.MaxDimension {
+		return ErrTooManyPixels
+	}
+	if cfg.Width*cfg.Height*frames > opts.MaxPixels {
+		return ErrTooManyPixels
+	}
+	return nil
+}
+
+func encodeResult(img image.Image, contentType string, opts Options) (*Result, error) {
+	original, err := encode(img, contentType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbs, err := thumbnails(img, contentType, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Original: *original, Thumbnails: thumbs}, nil
+}
+
+// processGIF keeps animation, only frames, delays, disposal and loop count
+// are written back
+func processGIF(data []byte, opts Options) (*Result, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(g.Image) == 0 {
+		return nil, ErrInvalid
+	}
+
+	var buf bytes.Buffer
+	clean := &gif.GIF{
+		Image:     g.Image,
+		Delay:     g.Delay,
+		Disposal:  g.Disposal,
+		LoopCount: g.LoopCount,
+		Config:    g.Config,
+	}
+	if err := gif.EncodeAll(&buf, clean); err != nil {
+		return nil, err
+	}
+
+	// thumbnails are still images of the first frame
+	first := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(first, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+
+	thumbs, err := thumbnails(first, "image/png", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Original: Image{
+			Data:        buf.Bytes(),
+			ContentType: "image/gif",
+			Ext:         ".gif",
+			Width:       g.Config.Width,
+			Height:      g.Config.Height,
+		},
+		Thumbnails: thumbs,
+	}, nil
+}
+
+// thumbnails scales img down to fit every size, smaller images are not upscaled
+func thumbnails(img image.Image, contentType string, opts Options) (map[int]Image, error) {
+	thumbs := make(map[int]Image, len(ThumbnailSizes))
+
+	for _, size := range ThumbnailSizes {
+		t, err := encode(fit(img, size), contentType, opts)
+		if err != nil {
+			return nil, err
+		}
+		thumbs[size] = *t
+	}
+
+	return thumbs, nil
+}
+
+func fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+	return dst
+}
+
+func encode(img image.Image, contentType string, opts Options) (*Image, error) {
+	var buf bytes.Buffer
+	out := &Image{
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+
+	switch contentType {
+	case "image/jpeg":
+		out.Ext = ".jpg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.JPEGQuality}); err != nil {
+			return nil, err
+		}
+	default:
+		out.ContentType = "image/png"
+		out.Ext = ".png"
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	}
+
+	out.Data = buf.Bytes()
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "script is escaped",
+			src:  "hi <script>alert(1)</script>",
+			want: "<p>hi &lt;script&gt;alert(1)&lt;/script&gt;</p>\n",
+		},
+		{
+			name: "raw html is not passed through",
+			src:  `<b>bold</b> <a href="https://example.com">a</a>`,
+			want: "<p>&lt;b&gt;bold&lt;/b&gt; &lt;a href=&#34;https://example.com&#34;&gt;a&lt;/a&gt;</p>\n",
+		},
+		{
+			name: "event handler attributes stay text",
+			src:  `<img src=x onerror=alert(1)>`,
+			want: "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n",
+		},
+		{
+			name: "javascript link",
+			src:  "[x](javascript:alert(1))",
+			want: "<p>x</p>\n",
+		},
+		{
+			name: "javascript link in mixed case",
+			src:  "[x](JaVaScRiPt:alert(1))",
+			want: "<p>x</p>\n",
+		},
+		{
+			name: "data link",
+			src:  "[x](data:text/html;base64,PHNjcmlwdD4=)",
+			want: "<p>x</p>\n",
+		},
+		{
+			name: "javascript autolink",
+			src:  "<javascript:alert(1)>",
+			want: "<p>javascript:alert(1)</p>\n",
+		},
+		{
+			name: "image is dropped",
+			src:  "![alt](https://example.com/a.png)",
+			want: "<p></p>\n",
+		},
+		{
+			name: "link",
+			src:  "[x](https://example.com)",
+			want: `<p><a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">x</a></p>` + "\n",
+		},
+		{
+			name: "bare url is linked",
+			src:  "see https://example.com/a",
+			want: `<p>see <a href="https://example.com/a" rel="nofollow noreferrer noopener" target="_blank">https://example.com/a</a></p>` + "\n",
+		},
+		{
+			name: "inline formatting",
+			src:  "**b** _e_ ~~d~~ `c`",
+			want: "<p><strong>b</strong> <em>e</em> <del>d</del> <code>c</code></p>\n",
+		},
+		{
+			name: "code block keeps language",
+			src:  "```go\nx := 1\n```",
+			want: "<pre><code class=\"language-go\">x := 1\n</code></pre>\n",
+		},
+		{
+			name: "headings are not parsed",
+			src:  "# title",
+			want: "<p># title</p>\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Render(tc.src)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Render(%q) =\n%q\nwant\n%q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestPolicy feeds html straight to the sanitizer, it must hold even if
+// the parser starts emitting raw html
+func TestPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "script",
+			html: `<p>a<script>alert(1)</script></p>`,
+			want: `<p>a</p>`,
+		},
+		{
+			name: "event handlers",
+			html: `<p onclick="alert(1)">a</p><code onmouseover="alert(1)">b</code>`,
+			want: `<p>a</p><code>b</code>`,
+		},
+		{
+			name: "image",
+			html: `<p><img src="https://example.com/a.png" onerror="alert(1)"></p>`,
+			want: `<p></p>`,
+		},
+		{
+			name: "javascript and data links",
+			html: `<a href="javascript:alert(1)">a</a><a href="data:text/html,x">b</a>`,
+			want: `ab`,
+		},
+		{
+			name: "link gets rel and target",
+			html: `<a href="https://example.com" onclick="alert(1)" rel="opener">a</a>`,
+			want: `<a href="https://example.com" rel="nofollow noreferrer noopener" target="_blank">a</a>`,
+		},
+		{
+			name: "unknown elements and classes",
+			html: `<div><iframe src="https://example.com"></iframe><code class="evil">x</code></div>`,
+			want: `<code>x</code>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := policy.Sanitize(tc.html); got != tc.want {
+				t.Errorf("Sanitize(%q) =\n%q\nwant\n%q", tc.html, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenderLinksAreNofollowNoreferrer(t *testing.T) {
+	got, err := Render("[a](https://example.com) and <https://example.org> and mailto:me@example.com")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	links := strings.Count(got, "<a ")
+	if links == 0 {
+		t.Fatalf("Render = %q, want links", got)
+	}
+	if n := strings.Count(got, `rel="nofollow noreferrer`); n != links {
+		t.Errorf("Render = %q, %d of %d links have rel nofollow noreferrer", got, n, links)
+	}
+}
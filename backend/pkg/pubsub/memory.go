@@ -0,0 +1,65 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBuffer is how many messages a subscriber can lag behind
+// before new ones are dropped
+const subscriberBuffer = 64
+
+// Memory delivers messages to subscribers of the same process
+type Memory struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan []byte]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{subs: map[string]map[chan []byte]struct{}{}}
+}
+
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	m.deliver(topic, payload)
+	return nil
+}
+
+// deliver never blocks, a full subscriber misses the message
+func (m *Memory) deliver(topic string, payload []byte) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for ch := range m.subs[topic] {
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+}
+
+func (m *Memory) Subscribe(topic string) (<-chan []byte, func()) {
+	ch := make(chan []byte, subscriberBuffer)
+
+	m.mu.Lock()
+	if m.subs[topic] == nil {
+		m.subs[topic] = map[chan []byte]struct{}{}
+	}
+	m.subs[topic][ch] = struct{}{}
+	m.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			delete(m.subs[topic], ch)
+			if len(m.subs[topic]) == 0 {
+				delete(m.subs, topic)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
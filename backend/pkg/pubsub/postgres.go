@@ -0,0 +1,104 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxNotifyPayload is the limit of a NOTIFY payload in PostgreSQL
+const maxNotifyPayload = 8000
+
+// reconnectDelay is how long the listener waits after losing its connection
+const reconnectDelay = 5 * time.Second
+
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+type envelope struct {
+	Topic   string `json:"t"`
+	Payload []byte `json:"p"`
+}
+
+// Postgres sends messages with NOTIFY on a single channel, every replica
+// LISTENs on it and delivers them to its own subscribers. Run must be
+// started for anything to be received, including own messages
+type Postgres struct {
+	db      *sql.DB
+	dsn     string
+	channel string
+	local   *Memory
+}
+
+// NewPostgres publishes through db, listening uses a dedicated connection to dsn
+func NewPostgres(db *sql.DB, dsn, channel string) *Postgres {
+	return &Postgres{
+		db:      db,
+		dsn:     dsn,
+		channel: channel,
+		local:   NewMemory(),
+	}
+}
+
+func (p *Postgres) Publish(ctx context.Context, topic string, payload []byte) error {
+	data, err := json.Marshal(envelope{Topic: topic, Payload: payload})
+	if err != nil {
+		return err
+	}
+	if len(data) > maxNotifyPayload {
+		return ErrPayloadTooLarge
+	}
+
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", p.channel, string(data))
+	return err
+}
+
+func (p *Postgres) Subscribe(topic string) (<-chan []byte, func()) {
+	return p.local.Subscribe(topic)
+}
+
+// Run listens until ctx is done, reconnecting after failures. Each
+// failure is passed to onError, messages sent meanwhile are lost
+func (p *Postgres) Run(ctx context.Context, onError func(error)) {
+	for {
+		err := p.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		onError(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (p *Postgres) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, p.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{p.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var e envelope
+		if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+			continue
+		}
+		p.local.deliver(e.Topic, e.Payload)
+	}
+}
@@ -0,0 +1,15 @@
+// Package pubsub delivers messages between parts of the backend. Memory
+// works within a single process, Postgres also across replicas
+package pubsub
+
+import "context"
+
+// PubSub publishes payloads to topics. Delivery is at most once, messages
+// are dropped for subscribers which don't keep up and lost while a
+// replica is disconnected
+type PubSub interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe returns channel receiving payloads published to topic and
+	// a function which cancels the subscription and closes the channel
+	Subscribe(topic string) (<-chan []byte, func())
+}
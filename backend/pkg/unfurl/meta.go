@@ -0,0 +1,135 @@
+package unfurl
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxURLLength         = 2048
+)
+
+// urlPattern finds bare links as well as targets of markdown links
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// FirstURL returns the first http(s) link in text, trailing punctuation
+// is not a part of it. Empty string means there is no link
+func FirstURL(text string) string {
+	for _, m := range urlPattern.FindAllString(text, -1) {
+		m = strings.TrimRight(m, ".,;:!?*_~`")
+		if len(m) > maxURLLength {
+			continue
+		}
+		u, err := url.Parse(m)
+		if err != nil || validateURL(u) != nil {
+			continue
+		}
+		return u.String()
+	}
+	return ""
+}
+
+// parseHead reads metadata from head of a page. OpenGraph wins over
+// Twitter cards, which win over plain <title> and description
+func parseHead(r io.Reader, base *url.URL) *Preview {
+	meta := map[string]string{}
+	title := ""
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return buildPreview(meta, title, base)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Body:
+				return buildPreview(meta, title, base)
+			case atom.Title:
+				if title == "" && z.Next() == html.TextToken {
+					title = string(z.Text())
+				}
+			case atom.Meta:
+				key, content := "", ""
+				for _, a := range tok.Attr {
+					switch a.Key {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(a.Val))
+						}
+					case "content":
+						content = a.Val
+					}
+				}
+				if _, seen := meta[key]; key != "" && !seen {
+					meta[key] = content
+				}
+			}
+
+		case html.EndTagToken:
+			if z.Token().DataAtom == atom.Head {
+				return buildPreview(meta, title, base)
+			}
+		}
+	}
+}
+
+func buildPreview(meta map[string]string, title string, base *url.URL) *Preview {
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := strings.TrimSpace(meta[k]); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	p := &Preview{
+		Title:       first("og:title", "twitter:title"),
+		Description: first("og:description", "twitter:description", "description"),
+		SiteName:    first("og:site_name"),
+		ImageURL:    resolveImage(first("og:image", "og:image:url", "og:image:secure_url", "twitter:image", "twitter:image:src"), base),
+	}
+	if p.Title == "" {
+		p.Title = strings.TrimSpace(title)
+	}
+
+	p.Title = truncate(collapseSpace(p.Title), maxTitleLength)
+	p.Description = truncate(collapseSpace(p.Description), maxDescriptionLength)
+	p.SiteName = truncate(collapseSpace(p.SiteName), maxTitleLength)
+
+	return p
+}
+
+// resolveImage makes image address absolute, only http(s) images are kept
+func resolveImage(raw string, base *url.URL) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := base.Parse(raw)
+	if err != nil || validateURL(u) != nil || len(u.String()) > maxURLLength {
+		return ""
+	}
+	return u.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
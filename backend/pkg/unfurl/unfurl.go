@@ -0,0 +1,187 @@
+// Package unfurl fetches web pages and extracts OpenGraph and Twitter
+// card metadata for link previews. Addresses are checked at dial time,
+// after DNS resolution, so hostnames resolving to private networks are
+// refused as well as literal addresses
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL        = errors.New("unfurl: only absolute http and https urls are allowed")
+	ErrBlockedAddress    = errors.New("unfurl: address is not public")
+	ErrTooManyRedirects  = errors.New("unfurl: too many redirects")
+	ErrUnexpectedStatus  = errors.New("unfurl: unexpected response status")
+	ErrUnsupportedFormat = errors.New("unfurl: response is not html")
+)
+
+const userAgent = "CritiqalBot/1.0 (+link preview)"
+
+type Config struct {
+	// Timeout limits the whole fetch including redirects
+	Timeout time.Duration
+	// MaxBodySize limits how much of a page is read, metadata lives in head
+	MaxBodySize  int64
+	MaxRedirects int
+	// AllowPrivate turns off address checks, it is meant for tests
+	// against local servers only
+	AllowPrivate bool
+}
+
+// Preview is metadata of a page, empty fields were not found
+type Preview struct {
+	// URL is the final address after redirects
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+type Fetcher struct {
+	client  *http.Client
+	maxBody int64
+}
+
+func New(cfg Config) *Fetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+	if cfg.MaxRedirects < 0 {
+		cfg.MaxRedirects = 0
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = checkAddress
+	}
+
+	transport := &http.Transport{
+		// proxies from environment would dial on our behalf, skipping the check
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > cfg.MaxRedirects {
+					return ErrTooManyRedirects
+				}
+				return validateURL(req.URL)
+			},
+		},
+		maxBody: cfg.MaxBodySize,
+	}
+}
+
+// Fetch downloads page at rawURL and parses its metadata
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if err := validateURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrUnsupportedFormat
+	}
+
+	p := parseHead(io.LimitReader(resp.Body, f.maxBody), resp.Request.URL)
+	p.URL = resp.Request.URL.String()
+
+	return p, nil
+}
+
+func validateURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// checkAddress is a dialer hook which refuses connections to anything
+// but public unicast addresses
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return ErrBlockedAddress
+	}
+	if !IsPublic(ap.Addr()) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// nonPublic are special-purpose ranges not covered by netip helpers
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// IsPublic reports whether addr is a globally routable unicast address
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+
+	for _, p := range nonPublic {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}